@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultAPIKeyHeader carries API keys unless APIKeyConfig.Header is set.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// APIKey grants access to the holder of a key.
+type APIKey struct {
+	ID    string   // Principal ID of the key holder, e.g. "billing-service"
+	Key   string   // Plain key; prefer Hash to keep keys out of the configuration
+	Hash  string   // Hex SHA-256 digest of the key, see HashAPIKey
+	Roles []string // Roles granted to the key holder
+}
+
+// APIKeyConfig configures an APIKeyAuthenticator.
+type APIKeyConfig struct {
+	Header string   // Request header carrying the key, defaults to DefaultAPIKeyHeader
+	Keys   []APIKey // Accepted keys
+}
+
+// APIKeyAuthenticator authenticates requests carrying one of the configured
+// API keys.
+type APIKeyAuthenticator struct {
+	header  string
+	keys    []APIKey
+	digests [][]byte
+}
+
+// NewAPIKeyAuthenticator creates an authenticator accepting the configured
+// keys. Each key needs either a plain key or a hash.
+func NewAPIKeyAuthenticator(config APIKeyConfig) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{header: config.Header, keys: config.Keys}
+	if a.header == "" {
+		a.header = DefaultAPIKeyHeader
+	}
+
+	for i, key := range config.Keys {
+		switch {
+		case key.Hash != "":
+			digest, err := hex.DecodeString(key.Hash)
+			if err != nil || len(digest) != sha256.Size {
+				return nil, fmt.Errorf("API key %d: hash is not a hex SHA-256 digest", i)
+			}
+			a.digests = append(a.digests, digest)
+		case key.Key != "":
+			digest := sha256.Sum256([]byte(key.Key))
+			a.digests = append(a.digests, digest[:])
+		default:
+			return nil, fmt.Errorf("API key %d: key or hash is required", i)
+		}
+	}
+	return a, nil
+}
+
+// HashAPIKey returns the hex SHA-256 digest of key, to configure APIKey.Hash.
+func HashAPIKey(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(digest[:])
+}
+
+// Authenticate implements Authenticator. Keys are compared by digest in
+// constant time.
+func (a *APIKeyAuthenticator) Authenticate(c *fiber.Ctx) (*Principal, error) {
+	key := c.Get(a.header)
+	if key == "" {
+		return nil, nil
+	}
+
+	digest := sha256.Sum256([]byte(key))
+	match := -1
+	for i, expected := range a.digests {
+		if subtle.ConstantTimeCompare(digest[:], expected) == 1 && match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return nil, state.NewUnauthorizedError("invalid API key")
+	}
+
+	return &Principal{
+		ID:     a.keys[match].ID,
+		Roles:  a.keys[match].Roles,
+		Method: MethodAPIKey,
+	}, nil
+}
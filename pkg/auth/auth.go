@@ -0,0 +1,139 @@
+// Package auth authenticates requests with JWT bearer tokens or API keys and
+// exposes the resulting principal to providers and processors.
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/n3crone/gapi-platform/pkg/logging"
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Authentication methods reported by Principal.Method
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID     string                 // Subject of the token or ID of the API key
+	Roles  []string               // Roles granted to the caller
+	Method string                 // Authentication method, MethodJWT or MethodAPIKey
+	Claims map[string]interface{} // Claims of the token, nil for API keys
+}
+
+// HasRole reports whether the principal was granted role.
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator identifies the caller from the credentials of a request.
+// It returns nil without an error when the request carries none of the
+// credentials it handles, so that the next authenticator is tried, and an
+// error when they are invalid. Errors of the state package, such as
+// state.UnauthorizedError, are sent as is; others are logged and answered
+// with a generic 401.
+type Authenticator interface {
+	Authenticate(c *fiber.Ctx) (*Principal, error)
+}
+
+// Challenger is implemented by authenticators of an HTTP authentication
+// scheme, sent in the WWW-Authenticate header of 401 responses.
+type Challenger interface {
+	Challenge() string
+}
+
+// Config configures the authentication middleware.
+type Config struct {
+	Authenticators []Authenticator         // Tried in order until one identifies the caller
+	Required       bool                    // Reject anonymous requests, otherwise they continue without a principal
+	Next           func(c *fiber.Ctx) bool // Skips the middleware when it returns true, e.g. for public routes
+}
+
+// Middleware authenticates requests with the configured authenticators and
+// stores the principal, see PrincipalOf. Invalid credentials are rejected
+// with 401 Unauthorized even when anonymous requests are allowed.
+//
+// Example usage:
+//
+//	jwtAuth, err := auth.NewJWTAuthenticator(auth.JWTConfig{
+//		JWKSURL:  "https://idp.example.com/.well-known/jwks.json",
+//		Issuer:   "https://idp.example.com/",
+//		Audience: []string{"orders-api"},
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	app.Use(auth.Middleware(auth.Config{
+//		Authenticators: []auth.Authenticator{jwtAuth},
+//		Required:       true,
+//	}))
+func Middleware(config Config) fiber.Handler {
+	var challenges []string
+	for _, authenticator := range config.Authenticators {
+		if challenger, ok := authenticator.(Challenger); ok {
+			challenges = append(challenges, challenger.Challenge())
+		}
+	}
+	challenge := strings.Join(challenges, ", ")
+
+	return func(c *fiber.Ctx) error {
+		if config.Next != nil && config.Next(c) {
+			return c.Next()
+		}
+
+		for _, authenticator := range config.Authenticators {
+			principal, err := authenticator.Authenticate(c)
+			if err != nil {
+				return reject(c, challenge, err)
+			}
+			if principal != nil {
+				c.Locals("principal", principal)
+				return c.Next()
+			}
+		}
+
+		if config.Required {
+			return reject(c, challenge, state.NewUnauthorizedError("authentication required"))
+		}
+		return c.Next()
+	}
+}
+
+// reject answers 401 with the authentication challenges. Unexpected errors
+// are logged and replaced so that their details stay out of responses.
+func reject(c *fiber.Ctx, challenge string, err error) error {
+	if challenge != "" {
+		c.Set(fiber.HeaderWWWAuthenticate, challenge)
+	}
+
+	var problemErr state.ProblemError
+	if errors.As(err, &problemErr) {
+		logging.Logger(c).Debug().Err(err).Msg("Authentication rejected")
+		return err
+	}
+	logging.Logger(c).Warn().Err(err).Msg("Authentication failed")
+	return state.NewUnauthorizedError("invalid credentials")
+}
+
+// PrincipalOf returns the caller of the request, or nil for anonymous
+// requests.
+//
+// Example usage in a processor:
+//
+//	if principal := auth.PrincipalOf(c); principal != nil {
+//		order.CreatedBy = principal.ID
+//	}
+func PrincipalOf(c *fiber.Ctx) *Principal {
+	principal, _ := c.Locals("principal").(*Principal)
+	return principal
+}
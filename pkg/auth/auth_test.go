@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAuthApp creates an app answering the ID of the principal, or
+// "anonymous".
+func setupAuthApp(config Config) *fiber.App {
+	app := fiber.New()
+	app.Use(Middleware(config))
+	app.Get("/", func(c *fiber.Ctx) error {
+		if principal := PrincipalOf(c); principal != nil {
+			return c.SendString(principal.Method + ":" + principal.ID)
+		}
+		return c.SendString("anonymous")
+	})
+	return app
+}
+
+// call sends a request with headers and returns the status, body and
+// authentication challenge of the response.
+func call(t *testing.T, app *fiber.App, headers map[string]string) (int, string, string) {
+	req := httptest.NewRequest("GET", "/", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body), resp.Header.Get(fiber.HeaderWWWAuthenticate)
+}
+
+type failingAuthenticator struct{ err error }
+
+func (a failingAuthenticator) Authenticate(*fiber.Ctx) (*Principal, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return nil, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	apiKeys, err := NewAPIKeyAuthenticator(APIKeyConfig{Keys: []APIKey{{ID: "billing", Key: "k1"}}})
+	require.NoError(t, err)
+	jwtAuth, err := NewJWTAuthenticator(JWTConfig{Secret: testSecret})
+	require.NoError(t, err)
+
+	t.Run("Lets anonymous requests through by default", func(t *testing.T) {
+		status, body, _ := call(t, setupAuthApp(Config{Authenticators: []Authenticator{apiKeys}}), nil)
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "anonymous", body)
+	})
+
+	t.Run("Rejects anonymous requests when required", func(t *testing.T) {
+		app := setupAuthApp(Config{Authenticators: []Authenticator{apiKeys, jwtAuth}, Required: true})
+
+		status, _, challenge := call(t, app, nil)
+		assert.Equal(t, fiber.StatusUnauthorized, status)
+		assert.Equal(t, "Bearer", challenge)
+	})
+
+	t.Run("Tries the authenticators in order", func(t *testing.T) {
+		app := setupAuthApp(Config{Authenticators: []Authenticator{failingAuthenticator{}, apiKeys, jwtAuth}})
+
+		status, body, _ := call(t, app, map[string]string{"Authorization": "Bearer " + signHS256(t, validClaims())})
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "jwt:42", body)
+
+		status, body, _ = call(t, app, map[string]string{DefaultAPIKeyHeader: "k1"})
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "api_key:billing", body)
+	})
+
+	t.Run("Rejects invalid credentials even when optional", func(t *testing.T) {
+		status, _, _ := call(t, setupAuthApp(Config{Authenticators: []Authenticator{apiKeys}}),
+			map[string]string{DefaultAPIKeyHeader: "wrong"})
+		assert.Equal(t, fiber.StatusUnauthorized, status)
+	})
+
+	t.Run("Hides unexpected errors", func(t *testing.T) {
+		var handled error
+		app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+			handled = err
+			return fiber.DefaultErrorHandler(c, err)
+		}})
+		app.Use(Middleware(Config{Authenticators: []Authenticator{failingAuthenticator{err: errors.New("dial tcp: refused")}}}))
+		app.Get("/", func(c *fiber.Ctx) error { return nil })
+
+		status, _, _ := call(t, app, nil)
+		assert.Equal(t, fiber.StatusUnauthorized, status)
+		var unauthorized *state.UnauthorizedError
+		require.ErrorAs(t, handled, &unauthorized)
+		assert.Equal(t, "invalid credentials", unauthorized.Detail)
+	})
+
+	t.Run("Skips requests matched by Next", func(t *testing.T) {
+		app := setupAuthApp(Config{
+			Authenticators: []Authenticator{apiKeys},
+			Required:       true,
+			Next:           func(c *fiber.Ctx) bool { return c.Query("public") != "" },
+		})
+		req := httptest.NewRequest("GET", "/?public=1", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	t.Run("Accepts plain and hashed keys", func(t *testing.T) {
+		apiKeys, err := NewAPIKeyAuthenticator(APIKeyConfig{
+			Header: "X-Token",
+			Keys: []APIKey{
+				{ID: "plain", Key: "first", Roles: []string{"reader"}},
+				{ID: "hashed", Hash: HashAPIKey("second"), Roles: []string{"admin"}},
+			},
+		})
+		require.NoError(t, err)
+		app := setupAuthApp(Config{Authenticators: []Authenticator{apiKeys}})
+
+		_, body, _ := call(t, app, map[string]string{"X-Token": "first"})
+		assert.Equal(t, "api_key:plain", body)
+		_, body, _ = call(t, app, map[string]string{"X-Token": "second"})
+		assert.Equal(t, "api_key:hashed", body)
+		_, body, _ = call(t, app, map[string]string{DefaultAPIKeyHeader: "second"})
+		assert.Equal(t, "anonymous", body, "only the configured header is read")
+	})
+
+	t.Run("Validates the configuration", func(t *testing.T) {
+		_, err := NewAPIKeyAuthenticator(APIKeyConfig{Keys: []APIKey{{ID: "empty"}}})
+		assert.ErrorContains(t, err, "key or hash is required")
+
+		_, err = NewAPIKeyAuthenticator(APIKeyConfig{Keys: []APIKey{{ID: "short", Hash: "abcd"}}})
+		assert.ErrorContains(t, err, "not a hex SHA-256 digest")
+	})
+}
+
+func TestPrincipal(t *testing.T) {
+	principal := &Principal{ID: "42", Roles: []string{"reader", "editor"}}
+	assert.True(t, principal.HasRole("editor"))
+	assert.False(t, principal.HasRole("admin"))
+}
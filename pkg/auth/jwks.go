@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultJWKSRefresh is the refresh interval of a JWKS URL unless
+// JWTConfig.JWKSRefresh is set.
+const DefaultJWKSRefresh = time.Hour
+
+// Bounds of JWKS URL fetches
+const (
+	jwksMinRefresh   = time.Minute      // Minimum delay between fetches triggered by unknown key IDs
+	jwksRetryDelay   = 5 * time.Second  // Minimum delay between fetches while no keys were ever loaded
+	jwksFetchTimeout = 10 * time.Second // Deadline of a fetch
+	jwksMaxSize      = 1 << 20          // Maximum size of a key set document
+)
+
+var (
+	errUnknownKey      = errors.New("no key matches the token")
+	errJWKSUnavailable = errors.New("JWKS unavailable")
+)
+
+// keySet holds the public keys of a JSON Web Key Set by key ID. Sets loaded
+// from a URL are refreshed periodically and when a token names an unknown
+// key, e.g. after a key rotation; a failed refresh keeps the previous keys.
+// Fetches are serialized and throttled so that tokens cannot be used to
+// flood the key set URL. They run without the lock: only requests needing
+// keys the set lacks wait for them.
+type keySet struct {
+	load    func(ctx context.Context) ([]byte, error)
+	refresh time.Duration // Zero for sets that never change
+
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey
+	loaded   time.Time
+	err      error         // Error of the last fetch
+	fetching chan struct{} // Closed when the fetch in flight completes, nil without one
+}
+
+// newFileKeySet creates a key set read from a file.
+func newFileKeySet(path string) *keySet {
+	return &keySet{load: func(context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}}
+}
+
+// newURLKeySet creates a key set fetched from a URL.
+func newURLKeySet(url string, refresh time.Duration) *keySet {
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefresh
+	}
+	return &keySet{refresh: refresh, load: func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize))
+	}}
+}
+
+// key returns the key with the given ID, or the only key of the set for
+// tokens without an ID.
+func (s *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.loaded)
+	_, known := s.keys[kid]
+	var stale bool
+	switch {
+	case s.keys == nil:
+		stale = s.loaded.IsZero() || age >= jwksRetryDelay
+	case s.refresh > 0:
+		stale = age >= s.refresh || (!known && age >= jwksMinRefresh)
+	}
+	switch {
+	case s.fetching != nil:
+		if !known {
+			if err := s.wait(ctx, s.fetching); err != nil {
+				return nil, fmt.Errorf("%w: %w", errJWKSUnavailable, err)
+			}
+		}
+	case stale:
+		_ = s.fetch(ctx)
+	}
+	if s.keys == nil {
+		return nil, s.err
+	}
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+	return nil, errUnknownKey
+}
+
+// preload fetches the set ahead of its first use.
+func (s *keySet) preload(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetch(ctx)
+}
+
+// fetch loads and parses the set, keeping the previous keys on failure. The
+// caller holds the lock, which is released while loading. The fetch is not
+// cancelled with the request that triggered it, as others may wait for it.
+func (s *keySet) fetch(ctx context.Context) error {
+	done := make(chan struct{})
+	s.fetching = done
+	s.loaded = time.Now()
+
+	s.mu.Unlock()
+	keys, err := s.read(context.WithoutCancel(ctx))
+	s.mu.Lock()
+
+	if err == nil {
+		s.keys = keys
+	}
+	s.err = err
+	s.fetching = nil
+	close(done)
+	return err
+}
+
+// read loads and parses the set.
+func (s *keySet) read(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	data, err := s.load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errJWKSUnavailable, err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errJWKSUnavailable, err)
+	}
+	return keys, nil
+}
+
+// wait releases the lock until the fetch in flight completes, or fails when
+// ctx is done first.
+func (s *keySet) wait(ctx context.Context, done <-chan struct{}) error {
+	s.mu.Unlock()
+	defer s.mu.Lock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// jwk is a JSON Web Key (RFC 7517) of type RSA or EC.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses the signature keys of a key set. Keys of other types
+// or uses are skipped.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid key set: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaKey()
+		case "EC":
+			key, err = k.ecdsaKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func (k jwk) ecdsaKey() (*ecdsa.PublicKey, error) {
+	var (
+		curve elliptic.Curve
+		check ecdh.Curve
+	)
+	switch k.Crv {
+	case "P-256":
+		curve, check = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, check = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, check = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	x, errX := base64.RawURLEncoding.DecodeString(k.X)
+	y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+	if errX != nil || errY != nil || len(x) != size || len(y) != size {
+		return nil, errors.New("invalid coordinates")
+	}
+
+	// Rejects points outside the curve
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := check.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
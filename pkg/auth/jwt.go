@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultRolesClaim lists the roles of a token unless JWTConfig.RolesClaim is set.
+const DefaultRolesClaim = "roles"
+
+// Algorithms accepted by default for each kind of key
+var (
+	hmacAlgorithms  = []string{"HS256", "HS384", "HS512"}
+	rsaAlgorithms   = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	ecdsaAlgorithms = []string{"ES256", "ES384", "ES512"}
+)
+
+// JWTConfig configures a JWTAuthenticator. At least one of Secret,
+// PublicKey, JWKSFile and JWKSURL is required.
+type JWTConfig struct {
+	Secret      []byte           // HMAC key verifying HS256, HS384 and HS512 tokens
+	PublicKey   crypto.PublicKey // RSA or ECDSA key verifying RS*, PS* and ES* tokens, see ParsePublicKey
+	JWKSFile    string           // JSON Web Key Set file, read once; keys are selected by the kid header
+	JWKSURL     string           // JSON Web Key Set URL, fetched on first use and refreshed every JWKSRefresh
+	JWKSRefresh time.Duration    // Refresh interval of JWKSURL, defaults to DefaultJWKSRefresh
+	Issuer      string           // Required iss claim, unchecked when empty
+	Audience    []string         // Accepted aud claims, unchecked when empty
+	Algorithms  []string         // Accepted algorithms, defaults to the ones of the configured keys
+	RolesClaim  string           // Claim listing the roles as an array or a space separated string, defaults to DefaultRolesClaim
+	Leeway      time.Duration    // Clock skew tolerated on the exp, nbf and iat claims
+}
+
+// JWTAuthenticator authenticates requests carrying a signed JWT in the
+// Authorization: Bearer header. Tokens must be unexpired and match the
+// configured issuer and audience.
+type JWTAuthenticator struct {
+	config JWTConfig
+	keys   *keySet
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator creates an authenticator verifying tokens with the
+// configured keys. A JWKS file is read immediately, a JWKS URL on the first
+// request.
+func NewJWTAuthenticator(config JWTConfig) (*JWTAuthenticator, error) {
+	if config.RolesClaim == "" {
+		config.RolesClaim = DefaultRolesClaim
+	}
+	a := &JWTAuthenticator{config: config}
+
+	algorithms := config.Algorithms
+	if len(config.Secret) > 0 {
+		algorithms = appendDefault(algorithms, config.Algorithms, hmacAlgorithms...)
+	}
+	switch config.PublicKey.(type) {
+	case nil:
+	case *rsa.PublicKey:
+		algorithms = appendDefault(algorithms, config.Algorithms, rsaAlgorithms...)
+	case *ecdsa.PublicKey:
+		algorithms = appendDefault(algorithms, config.Algorithms, ecdsaAlgorithms...)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", config.PublicKey)
+	}
+
+	switch {
+	case config.JWKSFile != "" && config.JWKSURL != "":
+		return nil, errors.New("JWKSFile and JWKSURL are mutually exclusive")
+	case config.JWKSFile != "":
+		a.keys = newFileKeySet(config.JWKSFile)
+		if err := a.keys.preload(context.Background()); err != nil {
+			return nil, err
+		}
+	case config.JWKSURL != "":
+		a.keys = newURLKeySet(config.JWKSURL, config.JWKSRefresh)
+	}
+	if a.keys != nil {
+		algorithms = appendDefault(algorithms, config.Algorithms, append(rsaAlgorithms, ecdsaAlgorithms...)...)
+	}
+
+	if len(algorithms) == 0 {
+		return nil, errors.New("a secret, public key or JWKS is required")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if len(config.Audience) > 0 {
+		options = append(options, jwt.WithAudience(config.Audience...))
+	}
+	a.parser = jwt.NewParser(options...)
+	return a, nil
+}
+
+// appendDefault appends the defaults to algorithms unless they were
+// configured explicitly.
+func appendDefault(algorithms, configured []string, defaults ...string) []string {
+	if len(configured) > 0 {
+		return algorithms
+	}
+	return append(algorithms, defaults...)
+}
+
+// Challenge implements Challenger.
+func (a *JWTAuthenticator) Challenge() string {
+	return "Bearer"
+}
+
+// Authenticate implements Authenticator. The principal ID is the sub claim.
+func (a *JWTAuthenticator) Authenticate(c *fiber.Ctx) (*Principal, error) {
+	header := c.Get(fiber.HeaderAuthorization)
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, nil
+	}
+
+	ctx := c.UserContext()
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(token), claims, func(t *jwt.Token) (interface{}, error) {
+		return a.key(ctx, t)
+	})
+	if err != nil {
+		// Key set failures are not the caller's fault, keep them private
+		if errors.Is(err, errJWKSUnavailable) {
+			return nil, err
+		}
+		return nil, state.NewUnauthorizedError("invalid token: " + strings.TrimPrefix(err.Error(), "token has invalid claims: "))
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return nil, state.NewUnauthorizedError("invalid token: " + err.Error())
+	}
+	return &Principal{
+		ID:     subject,
+		Roles:  stringsClaim(claims[a.config.RolesClaim]),
+		Method: MethodJWT,
+		Claims: claims,
+	}, nil
+}
+
+// key returns the key verifying a token. HMAC tokens only use the secret
+// and asymmetric ones only the public keys, so that a public key cannot be
+// abused as an HMAC secret.
+func (a *JWTAuthenticator) key(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(a.config.Secret) == 0 {
+			return nil, errUnknownKey
+		}
+		return a.config.Secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if a.keys != nil && (kid != "" || a.config.PublicKey == nil) {
+		return a.keys.key(ctx, kid)
+	}
+	if a.config.PublicKey == nil {
+		return nil, errUnknownKey
+	}
+	return a.config.PublicKey, nil
+}
+
+// stringsClaim reads a claim holding strings, either as an array or a space
+// separated string like the OAuth scope claim.
+func stringsClaim(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// ParsePublicKey parses a PEM encoded RSA or ECDSA public key or
+// certificate, to configure JWTConfig.PublicKey.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
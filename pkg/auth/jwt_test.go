@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "42",
+		"iss":   "https://idp.example.com/",
+		"aud":   "orders-api",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"reader", "editor"},
+	}
+}
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	require.NoError(t, err)
+	return token
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// jwksDocument renders the public keys as a JSON Web Key Set.
+func jwksDocument(t *testing.T, keys map[string]interface{}) []byte {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "EC", "kid": kid, "crv": key.Curve.Params().Name,
+				"x": encode(key.X.FillBytes(make([]byte, 32))), "y": encode(key.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	return data
+}
+
+// authenticate runs the authenticator on a request with the bearer token.
+func authenticate(t *testing.T, a *JWTAuthenticator, token string) (*Principal, error) {
+	var (
+		principal *Principal
+		authErr   error
+	)
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		principal, authErr = a.Authenticate(c)
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	_, err := app.Test(req)
+	require.NoError(t, err)
+	return principal, authErr
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("Verifies HMAC tokens", func(t *testing.T) {
+		a, err := NewJWTAuthenticator(JWTConfig{Secret: testSecret})
+		require.NoError(t, err)
+
+		principal, err := authenticate(t, a, signHS256(t, validClaims()))
+		require.NoError(t, err)
+		assert.Equal(t, "42", principal.ID)
+		assert.Equal(t, MethodJWT, principal.Method)
+		assert.Equal(t, []string{"reader", "editor"}, principal.Roles)
+		assert.Equal(t, "orders-api", principal.Claims["aud"])
+	})
+
+	t.Run("Verifies tokens with a PEM public key", func(t *testing.T) {
+		der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		require.NoError(t, err)
+		publicKey, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		require.NoError(t, err)
+
+		a, err := NewJWTAuthenticator(JWTConfig{PublicKey: publicKey})
+		require.NoError(t, err)
+
+		for _, method := range []jwt.SigningMethod{jwt.SigningMethodRS256, jwt.SigningMethodPS384} {
+			principal, err := authenticate(t, a, sign(t, method, "", rsaKey, validClaims()))
+			require.NoError(t, err, method.Alg())
+			assert.Equal(t, "42", principal.ID)
+		}
+	})
+
+	t.Run("Rejects the public key used as an HMAC secret", func(t *testing.T) {
+		der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		require.NoError(t, err)
+		pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+		a, err := NewJWTAuthenticator(JWTConfig{PublicKey: &rsaKey.PublicKey})
+		require.NoError(t, err)
+
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodHS256, "", pemKey, validClaims()))
+		assert.ErrorContains(t, err, "signing method HS256 is invalid")
+	})
+
+	t.Run("Selects JWKS file keys by key ID", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, os.WriteFile(path, jwksDocument(t, map[string]interface{}{
+			"rsa": &rsaKey.PublicKey,
+			"ec":  &ecKey.PublicKey,
+		}), 0o600))
+
+		a, err := NewJWTAuthenticator(JWTConfig{JWKSFile: path})
+		require.NoError(t, err)
+
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims()))
+		assert.NoError(t, err)
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodES256, "ec", ecKey, validClaims()))
+		assert.NoError(t, err)
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodES256, "rsa", ecKey, validClaims()))
+		assert.Error(t, err, "keys of another kid are rejected")
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodES256, "", ecKey, validClaims()))
+		assert.ErrorContains(t, err, errUnknownKey.Error(), "tokens without kid need a single key")
+	})
+
+	t.Run("Fetches and refreshes a JWKS URL", func(t *testing.T) {
+		var (
+			document atomic.Value
+			fetches  atomic.Int32
+		)
+		document.Store(jwksDocument(t, map[string]interface{}{"v1": &rsaKey.PublicKey}))
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			_, _ = w.Write(document.Load().([]byte))
+		}))
+		defer server.Close()
+
+		a, err := NewJWTAuthenticator(JWTConfig{JWKSURL: server.URL})
+		require.NoError(t, err)
+		assert.Equal(t, int32(0), fetches.Load(), "keys are fetched on first use")
+
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodRS256, "v1", rsaKey, validClaims()))
+		require.NoError(t, err)
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodRS256, "v1", rsaKey, validClaims()))
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), fetches.Load())
+
+		// Rotation: unknown key IDs trigger a throttled refresh
+		document.Store(jwksDocument(t, map[string]interface{}{"v2": &ecKey.PublicKey}))
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodES256, "v2", ecKey, validClaims()))
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), fetches.Load())
+
+		a.keys.loaded = a.keys.loaded.Add(-jwksMinRefresh)
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodES256, "v2", ecKey, validClaims()))
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), fetches.Load())
+	})
+
+	t.Run("Refreshes a JWKS URL without blocking known keys", func(t *testing.T) {
+		var fetches atomic.Int32
+		fetching, release := make(chan struct{}), make(chan struct{})
+		document := jwksDocument(t, map[string]interface{}{"v1": &rsaKey.PublicKey, "v2": &ecKey.PublicKey})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if fetches.Add(1) > 1 {
+				fetching <- struct{}{}
+				<-release
+			}
+			_, _ = w.Write(document)
+		}))
+		defer server.Close()
+
+		a, err := NewJWTAuthenticator(JWTConfig{JWKSURL: server.URL})
+		require.NoError(t, err)
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodRS256, "v1", rsaKey, validClaims()))
+		require.NoError(t, err)
+
+		// The periodic refresh stalls while the known keys keep working
+		a.keys.loaded = a.keys.loaded.Add(-DefaultJWKSRefresh)
+		refreshed := make(chan error, 1)
+		go func() {
+			_, err := a.keys.key(context.Background(), "v2")
+			refreshed <- err
+		}()
+		<-fetching
+
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodRS256, "v1", rsaKey, validClaims()))
+		require.NoError(t, err)
+
+		close(release)
+		require.NoError(t, <-refreshed)
+		assert.Equal(t, int32(2), fetches.Load())
+	})
+
+	t.Run("Keeps key set failures private", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		a, err := NewJWTAuthenticator(JWTConfig{JWKSURL: server.URL})
+		require.NoError(t, err)
+
+		_, err = authenticate(t, a, sign(t, jwt.SigningMethodRS256, "v1", rsaKey, validClaims()))
+		assert.ErrorIs(t, err, errJWKSUnavailable)
+	})
+
+	t.Run("Checks the claims", func(t *testing.T) {
+		a, err := NewJWTAuthenticator(JWTConfig{
+			Secret:   testSecret,
+			Issuer:   "https://idp.example.com/",
+			Audience: []string{"orders-api", "billing-api"},
+		})
+		require.NoError(t, err)
+
+		tests := map[string]func(jwt.MapClaims){
+			"token is expired":                func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+			"token is missing required claim": func(c jwt.MapClaims) { delete(c, "exp") },
+			"token has invalid issuer":        func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com/" },
+			"token has invalid audience":      func(c jwt.MapClaims) { c["aud"] = "other-api" },
+		}
+		for expected, tamper := range tests {
+			claims := validClaims()
+			tamper(claims)
+			_, err := authenticate(t, a, signHS256(t, claims))
+			assert.ErrorContains(t, err, expected)
+		}
+
+		_, err = authenticate(t, a, signHS256(t, validClaims()))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Reads roles from a custom claim", func(t *testing.T) {
+		a, err := NewJWTAuthenticator(JWTConfig{Secret: testSecret, RolesClaim: "scope"})
+		require.NoError(t, err)
+
+		claims := validClaims()
+		claims["scope"] = "orders:read orders:write"
+		principal, err := authenticate(t, a, signHS256(t, claims))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders:read", "orders:write"}, principal.Roles)
+	})
+
+	t.Run("Validates the configuration", func(t *testing.T) {
+		_, err := NewJWTAuthenticator(JWTConfig{})
+		assert.Error(t, err)
+
+		_, err = NewJWTAuthenticator(JWTConfig{JWKSFile: filepath.Join(t.TempDir(), "missing.json")})
+		assert.ErrorIs(t, err, errJWKSUnavailable)
+	})
+}
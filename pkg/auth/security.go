@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Vote is the decision of a Voter.
+type Vote int
+
+const (
+	// VoteAbstain leaves the decision to the other voters and the
+	// operation's Security.
+	VoteAbstain Vote = iota
+	// VoteGrant allows the operation, skipping the roles and check of its
+	// Security, unless another voter denies it.
+	VoteGrant
+	// VoteDeny forbids the operation whatever the other voters and the
+	// Security decide.
+	VoteDeny
+)
+
+// Voter takes part in the authorization of every operation it is
+// registered for, e.g. to let owners edit their records or to lock out
+// suspended accounts. Voters abstain on operations and subjects they do not
+// handle. The subject is the loaded record for item operations and nil for
+// collection operations; principal is nil for anonymous requests.
+type Voter interface {
+	Vote(c *fiber.Ctx, principal *Principal, operation string, subject interface{}) Vote
+}
+
+// VoterFunc adapts a function to the Voter interface.
+type VoterFunc func(c *fiber.Ctx, principal *Principal, operation string, subject interface{}) Vote
+
+// Vote calls f(c, principal, operation, subject).
+func (f VoterFunc) Vote(c *fiber.Ctx, principal *Principal, operation string, subject interface{}) Vote {
+	return f(c, principal, operation, subject)
+}
+
+// Security restricts an operation. The caller needs one of the Roles, when
+// set, and Check must pass, when set. Anonymous callers are rejected unless
+// the Security only has a Check, which then decides for them too; an empty
+// Security thus only requires an authenticated caller.
+type Security struct {
+	Roles   []string                                                           // Roles allowed to perform the operation, any of them suffices
+	Check   func(c *fiber.Ctx, principal *Principal, subject interface{}) bool // Predicate over the caller and the subject, see Voter; principal is nil for anonymous requests
+	Voters  []Voter                                                            // Voters of this operation, consulted after the global ones
+	Message string                                                             // Detail of 403 responses, defaults to "access denied"
+}
+
+// Authorize decides whether the caller of the request may perform the
+// operation on subject:
+//  1. Any voter denying it forbids the operation
+//  2. Otherwise any voter granting it allows the operation
+//  3. Otherwise the Security decides, allowing everyone when nil
+//
+// Denied anonymous callers get a 401 Unauthorized error, authenticated ones
+// a 403 Forbidden error.
+func Authorize(c *fiber.Ctx, security *Security, voters []Voter, operation string, subject interface{}) error {
+	principal := PrincipalOf(c)
+	if security != nil {
+		voters = append(voters[:len(voters):len(voters)], security.Voters...)
+	}
+
+	granted := false
+	for _, voter := range voters {
+		switch voter.Vote(c, principal, operation, subject) {
+		case VoteDeny:
+			return deny(principal, security)
+		case VoteGrant:
+			granted = true
+		}
+	}
+	if granted || security == nil || security.allows(c, principal, subject) {
+		return nil
+	}
+	return deny(principal, security)
+}
+
+// allows checks the roles and predicate of the security.
+func (s *Security) allows(c *fiber.Ctx, principal *Principal, subject interface{}) bool {
+	if s.Check == nil && principal == nil {
+		return false
+	}
+	if len(s.Roles) > 0 {
+		if principal == nil {
+			return false
+		}
+		allowed := false
+		for _, role := range s.Roles {
+			allowed = allowed || principal.HasRole(role)
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return s.Check == nil || s.Check(c, principal, subject)
+}
+
+// deny returns the error refusing the operation to principal.
+func deny(principal *Principal, security *Security) error {
+	if principal == nil {
+		return state.NewUnauthorizedError("authentication required")
+	}
+	message := "access denied"
+	if security != nil && security.Message != "" {
+		message = security.Message
+	}
+	return state.NewForbiddenError(message)
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authorize runs Authorize in a request of principal.
+func authorize(t *testing.T, principal *Principal, security *Security, voters []Voter, subject interface{}) error {
+	var authErr error
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		if principal != nil {
+			c.Locals("principal", principal)
+		}
+		authErr = Authorize(c, security, voters, "update", subject)
+		return nil
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	return authErr
+}
+
+func vote(v Vote) Voter {
+	return VoterFunc(func(*fiber.Ctx, *Principal, string, interface{}) Vote { return v })
+}
+
+func TestAuthorize(t *testing.T) {
+	reader := &Principal{ID: "1", Roles: []string{"reader"}}
+	admin := &Principal{ID: "2", Roles: []string{"admin"}}
+	owns := func(_ *fiber.Ctx, p *Principal, subject interface{}) bool {
+		return p != nil && subject == p.ID
+	}
+
+	tests := map[string]struct {
+		principal *Principal
+		security  *Security
+		voters    []Voter
+		subject   interface{}
+		status    int // 0 when allowed
+	}{
+		"No security allows everyone":          {nil, nil, nil, nil, 0},
+		"Empty security requires a principal":  {nil, &Security{}, nil, nil, fiber.StatusUnauthorized},
+		"Empty security allows any principal":  {reader, &Security{}, nil, nil, 0},
+		"Roles reject anonymous callers":       {nil, &Security{Roles: []string{"admin"}}, nil, nil, fiber.StatusUnauthorized},
+		"Roles reject other roles":             {reader, &Security{Roles: []string{"admin", "editor"}}, nil, nil, fiber.StatusForbidden},
+		"Roles allow any listed role":          {admin, &Security{Roles: []string{"editor", "admin"}}, nil, nil, 0},
+		"Check decides on the subject":         {reader, &Security{Check: owns}, nil, "1", 0},
+		"Failed checks are forbidden":          {reader, &Security{Check: owns}, nil, "2", fiber.StatusForbidden},
+		"Check decides for anonymous callers":  {nil, &Security{Check: func(*fiber.Ctx, *Principal, interface{}) bool { return true }}, nil, nil, 0},
+		"Roles and check must both pass":       {admin, &Security{Roles: []string{"admin"}, Check: owns}, nil, "1", fiber.StatusForbidden},
+		"Abstaining voters leave the decision": {reader, &Security{Roles: []string{"admin"}}, []Voter{vote(VoteAbstain)}, nil, fiber.StatusForbidden},
+		"Granting voters skip the security":    {reader, &Security{Roles: []string{"admin"}}, []Voter{vote(VoteAbstain), vote(VoteGrant)}, nil, 0},
+		"Denying voters win over granting":     {admin, nil, []Voter{vote(VoteGrant), vote(VoteDeny)}, nil, fiber.StatusForbidden},
+		"Denying voters reject anonymous":      {nil, nil, []Voter{vote(VoteDeny)}, nil, fiber.StatusUnauthorized},
+		"Operation voters are consulted":       {admin, &Security{Voters: []Voter{vote(VoteDeny)}}, nil, nil, fiber.StatusForbidden},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := authorize(t, tt.principal, tt.security, tt.voters, tt.subject)
+			if tt.status == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var problemErr state.ProblemError
+			require.ErrorAs(t, err, &problemErr)
+			assert.Equal(t, tt.status, problemErr.Problem().Status)
+		})
+	}
+
+	t.Run("Uses the configured message", func(t *testing.T) {
+		err := authorize(t, reader, &Security{Roles: []string{"admin"}, Message: "only admins may edit"}, nil, nil)
+		var forbidden *state.ForbiddenError
+		require.ErrorAs(t, err, &forbidden)
+		assert.Equal(t, "only admins may edit", forbidden.Detail)
+	})
+
+	t.Run("Passes the operation and subject to voters", func(t *testing.T) {
+		var seen []interface{}
+		voter := VoterFunc(func(_ *fiber.Ctx, p *Principal, operation string, subject interface{}) Vote {
+			seen = append(seen, p.ID, operation, subject)
+			return VoteAbstain
+		})
+		require.NoError(t, authorize(t, reader, nil, []Voter{voter}, "record"))
+		assert.Equal(t, []interface{}{"1", "update", "record"}, seen)
+	})
+}
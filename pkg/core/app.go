@@ -3,9 +3,13 @@ package core
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/n3crone/gapi-platform/pkg/auth"
 	"github.com/n3crone/gapi-platform/pkg/database"
+	"github.com/n3crone/gapi-platform/pkg/logging"
 	"github.com/n3crone/gapi-platform/pkg/resource"
+	"github.com/n3crone/gapi-platform/pkg/tracing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog"
@@ -14,24 +18,51 @@ import (
 // App represents the main application structure that combines Fiber web framework
 // with resource management and database connectivity.
 type App struct {
-	Fiber *fiber.App                // Embedded Fiber application instance
-	Db    database.DB               // Database connection interface
-	rm    *resource.ResourceManager // Resource manager for handling API resources
-	log   zerolog.Logger            // Application logger
+	Fiber            *fiber.App                // Embedded Fiber application instance
+	Db               database.DB               // Database connection interface
+	rm               *resource.ResourceManager // Resource manager for handling API resources
+	log              zerolog.Logger            // Application logger
+	problemEnrichers []ProblemEnricher         // Hooks adding members to error responses
+	resources        []resource.ResourceConfig // Configurations of registered resources
+	openAPI          *OpenAPIConfig            // OpenAPI document settings, nil when disabled
+	explorer         *ExplorerConfig           // API explorer settings, nil when disabled
+	addr             string                    // Listen address used by Run
+	shutdownTimeout  time.Duration             // Deadline for draining requests and running hooks
+	shutdownHooks    []ShutdownHook            // Hooks run by Run in reverse order
+	healthChecks     []HealthCheck             // Checks run by the probe endpoints
 }
 
 type Config struct {
-	FiberConfig *fiber.Config // Fiber configuration settings
-	DatabaseUri string        // Database connection URI
-	LogLevel    zerolog.Level // Log level for the application
-	LogFormat   string        // Log format for the application
+	FiberConfig      *fiber.Config     // Fiber configuration settings
+	DatabaseUri      string            // Database connection URI
+	Database         database.Config   // Connection pool and health thresholds, unset values come from DB_* env vars
+	LogLevel         zerolog.Level     // Log level for the application
+	LogFormat        string            // Log format for the application
+	ProblemEnrichers []ProblemEnricher // Hooks adding members to problem+json error responses
+	OpenAPI          *OpenAPIConfig    // Serves the generated OpenAPI document when set
+	Explorer         *ExplorerConfig   // Serves a bundled Swagger UI explorer when set, implies OpenAPI
+	Addr             string            // Listen address used by Run, defaults to DefaultAddr
+	ShutdownTimeout  time.Duration     // Deadline for a graceful shutdown, defaults to DefaultShutdownTimeout
+	HealthChecks     []HealthCheck     // Checks added to the probes besides the database
+	Metrics          *MetricsConfig    // Serves Prometheus metrics when set
+	Tracing          *tracing.Config   // Traces requests with OpenTelemetry when set
+	Logging          logging.Config    // Request ID and access log settings
+	Auth             *auth.Config      // Authenticates requests with JWTs or API keys when set
 }
 
 // New creates and initializes a new App instance with the provided configuration.
 // It sets up the core components of the application:
 //   - Configures structured logging with the specified level and format
-//   - Establishes a database connection using the provided URI
+//   - Establishes a database connection using the provided URI and pool settings
 //   - Initializes a Fiber web server with custom or default configuration
+//   - Installs an RFC 7807 problem+json error handler unless one is configured
+//   - Checks the database on the readiness and startup probes
+//   - Traces requests with OpenTelemetry if enabled
+//   - Tags requests with an X-Request-ID, a request logger and an access log
+//   - Measures resource requests and serves them to Prometheus if enabled
+//   - Renders errors once, before the middleware above reads the status sent
+//   - Authenticates requests with the configured authenticators if enabled
+//   - Serves the OpenAPI document and explorer behind that middleware if enabled
 //   - Sets up a resource manager for API endpoint handling
 //
 // Example usage:
@@ -72,7 +103,9 @@ func New(config Config) (*App, error) {
 	}
 
 	logger.Info().Msg("Establishing database connection")
-	db, err := database.New(config.DatabaseUri, logger)
+	db, err := database.New(config.DatabaseUri, logger, func(c *database.Config) {
+		*c = config.Database
+	})
 	if err != nil {
 		logger.Fatal().
 			Err(err).
@@ -85,11 +118,41 @@ func New(config Config) (*App, error) {
 	rm := resource.NewResourceManager(db.GetOrm(), &logger)
 
 	app := &App{
-		Fiber: fiber.New(fiberConfig),
-		Db:    db,
-		rm:    rm,
-		log:   logger,
+		Db:               db,
+		rm:               rm,
+		log:              logger,
+		problemEnrichers: config.ProblemEnrichers,
+		openAPI:          config.OpenAPI,
+		explorer:         config.Explorer,
+		addr:             config.Addr,
+		shutdownTimeout:  config.ShutdownTimeout,
 	}
+	app.AddHealthCheck(HealthCheck{Name: "database", Checker: databaseChecker{db: db}})
+	app.AddHealthCheck(config.HealthChecks...)
+
+	if fiberConfig.ErrorHandler == nil {
+		fiberConfig.ErrorHandler = app.errorHandler
+	}
+	app.Fiber = fiber.New(fiberConfig)
+
+	if config.Tracing != nil {
+		if err := app.registerTracing(*config.Tracing, fiberConfig.AppName); err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Failed to configure tracing")
+			db.Close()
+			return nil, err
+		}
+	}
+	app.Fiber.Use(logging.Middleware(logger, config.Logging))
+	if config.Metrics != nil {
+		app.registerMetrics(*config.Metrics)
+	}
+	app.Fiber.Use(renderErrors)
+	if config.Auth != nil {
+		app.registerAuth(*config.Auth, config.Metrics)
+	}
+	app.registerDocumentationRoutes()
 
 	logger.Info().
 		Str("app_name", fiberConfig.AppName).
@@ -125,6 +188,8 @@ func (a *App) Migrate(models ...interface{}) error {
 // configureLogger sets up the zerolog logger with the specified level and format.
 // If level is not provided (0), it defaults to Debug level.
 // Format can be either "json" or "console" (pretty print).
+// Events carrying a traced context, see zerolog.Event.Ctx, get trace_id and
+// span_id fields.
 //
 // Parameters:
 //   - level: The minimum log level to output (Debug, Info, Warn, Error, Fatal)
@@ -141,13 +206,13 @@ func configureLogger(level zerolog.Level, format string) zerolog.Logger {
 
 	var logger zerolog.Logger
 	if format == "json" {
-		logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+		logger = zerolog.New(os.Stdout).With().Timestamp().Logger().Hook(tracing.LogHook{})
 	} else {
 		output := zerolog.ConsoleWriter{
 			Out:        os.Stdout,
 			TimeFormat: "2006-01-02 15:04:05",
 		}
-		logger = zerolog.New(output).With().Timestamp().Logger()
+		logger = zerolog.New(output).With().Timestamp().Logger().Hook(tracing.LogHook{})
 	}
 
 	logger.Debug().
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/n3crone/gapi-platform/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerAuth installs the authentication middleware. The probe, /health
+// and metrics endpoints stay public, so that orchestrators and scrapers
+// need no credentials even when authentication is required.
+func (a *App) registerAuth(config auth.Config, metrics *MetricsConfig) {
+	public := []string{"/health", "/" + string(ProbeLiveness), "/" + string(ProbeReadiness), "/" + string(ProbeStartup)}
+	if metrics != nil {
+		path := metrics.Path
+		if path == "" {
+			path = DefaultMetricsPath
+		}
+		public = append(public, path)
+	}
+
+	next := config.Next
+	config.Next = func(c *fiber.Ctx) bool {
+		path := c.Path()
+		for _, prefix := range public {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+		}
+		return next != nil && next(c)
+	}
+	a.Fiber.Use(auth.Middleware(config))
+
+	a.log.Info().
+		Int("authenticators", len(config.Authenticators)).
+		Bool("required", config.Required).
+		Msg("Authentication enabled")
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/pkg/database"
+	"github.com/n3crone/gapi-platform/pkg/resource"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth(t *testing.T) {
+	db, err := database.New("sqlite://:memory:", zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.AutoMigrate(&Book{}))
+
+	apiKeys, err := auth.NewAPIKeyAuthenticator(auth.APIKeyConfig{
+		Keys: []auth.APIKey{{ID: "librarian", Hash: auth.HashAPIKey("secret")}},
+	})
+	require.NoError(t, err)
+
+	logger := zerolog.Nop()
+	app := &App{
+		Db:  db,
+		log: logger,
+		rm:  resource.NewResourceManager(db.GetOrm(), &logger),
+	}
+	app.Fiber = fiber.New(fiber.Config{ErrorHandler: app.errorHandler})
+	app.registerAuth(auth.Config{
+		Authenticators: []auth.Authenticator{apiKeys},
+		Required:       true,
+	}, &MetricsConfig{})
+	app.registerMetrics(MetricsConfig{})
+	app.RegisterHealthRoutes()
+	app.RegisterResource(&Book{})
+
+	t.Run("Rejects anonymous resource requests", func(t *testing.T) {
+		resp, err := app.Fiber.Test(httptest.NewRequest("GET", "/books", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "application/problem+json", resp.Header.Get(fiber.HeaderContentType))
+	})
+
+	t.Run("Accepts authenticated resource requests", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/books", nil)
+		req.Header.Set(auth.DefaultAPIKeyHeader, "secret")
+		resp, err := app.Fiber.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Keeps probes and metrics public", func(t *testing.T) {
+		for _, target := range []string{"/livez", "/readyz", "/readyz/ping", "/metrics"} {
+			resp, err := app.Fiber.Test(httptest.NewRequest("GET", target, nil))
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode, target)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProblemContentType is the media type of RFC 7807 error responses.
+const ProblemContentType = "application/problem+json"
+
+// ProblemEnricher adds members to a problem before it is rendered,
+// typically extensions such as trace or request IDs.
+//
+// Example usage:
+//
+//	app.OnProblem(func(c *fiber.Ctx, err error, problem *state.Problem) {
+//		problem.Extensions["traceId"] = c.Get("traceparent")
+//	})
+type ProblemEnricher func(c *fiber.Ctx, err error, problem *state.Problem)
+
+// OnProblem registers an enricher that is applied to every problem response
+// in registration order.
+func (a *App) OnProblem(enricher ProblemEnricher) {
+	a.problemEnrichers = append(a.problemEnrichers, enricher)
+}
+
+// errorHandler renders every error returned by handlers as application/problem+json.
+// Typed errors from the state package keep their status and detail, Fiber
+// errors keep their status and message, and any other error becomes a 500
+// without details so that internal messages never leak to clients.
+func (a *App) errorHandler(c *fiber.Ctx, err error) error {
+	problem := problemFromError(err)
+	problem.Instance = c.OriginalURL()
+	if problem.Extensions == nil {
+		problem.Extensions = make(map[string]interface{})
+	}
+
+	for _, enrich := range a.problemEnrichers {
+		enrich(c, err, problem)
+	}
+
+	logger := a.requestLogger(c)
+	if problem.Status >= fiber.StatusInternalServerError {
+		logger.Error().
+			Ctx(c.UserContext()).
+			Err(err).
+			Int("status", problem.Status).
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Msg("Request failed")
+	} else {
+		logger.Debug().
+			Ctx(c.UserContext()).
+			Err(err).
+			Int("status", problem.Status).
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Msg("Request rejected")
+	}
+
+	return c.Status(problem.Status).JSON(problem, ProblemContentType)
+}
+
+// renderErrors renders the errors returned by the handlers it wraps with the
+// application error handler and records them on the request span, if any.
+// New installs it after the tracing, logging and metrics middleware, so that
+// they read the status code sent instead of rendering errors themselves.
+func renderErrors(c *fiber.Ctx) error {
+	err := c.Next()
+	if err == nil {
+		return nil
+	}
+
+	trace.SpanFromContext(c.UserContext()).RecordError(err)
+	if err := c.App().ErrorHandler(c, err); err != nil {
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	return nil
+}
+
+// problemFromError converts an error into problem details.
+func problemFromError(err error) *state.Problem {
+	var problemErr state.ProblemError
+	if errors.As(err, &problemErr) {
+		return problemErr.Problem()
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		problem := &state.Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(fiberErr.Code),
+			Status: fiberErr.Code,
+		}
+		if fiberErr.Message != problem.Title {
+			problem.Detail = fiberErr.Message
+		}
+		return problem
+	}
+
+	return &state.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(fiber.StatusInternalServerError),
+		Status: fiber.StatusInternalServerError,
+	}
+}
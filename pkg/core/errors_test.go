@@ -0,0 +1,148 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupErrorApp(enrichers ...ProblemEnricher) (*App, *fiber.App) {
+	app := &App{log: zerolog.Nop(), problemEnrichers: enrichers}
+	app.Fiber = fiber.New(fiber.Config{ErrorHandler: app.errorHandler})
+	return app, app.Fiber
+}
+
+func requestProblem(t *testing.T, app *fiber.App, target string) (int, string, map[string]interface{}) {
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var problem map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &problem), string(body))
+	return resp.StatusCode, resp.Header.Get("Content-Type"), problem
+}
+
+func TestErrorHandler(t *testing.T) {
+	t.Run("Renders typed errors as problem details", func(t *testing.T) {
+		_, app := setupErrorApp()
+		app.Get("/items/:id", func(c *fiber.Ctx) error {
+			return state.NewNotFoundError("record not found")
+		})
+
+		status, contentType, problem := requestProblem(t, app, "/items/7?x=1")
+		assert.Equal(t, fiber.StatusNotFound, status)
+		assert.Equal(t, ProblemContentType, contentType)
+		assert.Equal(t, map[string]interface{}{
+			"type":     "about:blank",
+			"title":    "Not Found",
+			"status":   float64(404),
+			"detail":   "record not found",
+			"instance": "/items/7?x=1",
+		}, problem)
+	})
+
+	t.Run("Keeps custom type, title and extensions", func(t *testing.T) {
+		_, app := setupErrorApp()
+		app.Get("/", func(c *fiber.Ctx) error {
+			err := state.NewConflictError("email already taken", nil)
+			err.Type = "https://example.com/problems/duplicate"
+			err.Title = "Duplicate"
+			err.Extensions = map[string]interface{}{"field": "email", "status": "ignored"}
+			return err
+		})
+
+		status, _, problem := requestProblem(t, app, "/")
+		assert.Equal(t, fiber.StatusConflict, status)
+		assert.Equal(t, "https://example.com/problems/duplicate", problem["type"])
+		assert.Equal(t, "Duplicate", problem["title"])
+		assert.Equal(t, "email", problem["field"])
+		assert.Equal(t, float64(409), problem["status"])
+	})
+
+	t.Run("Converts Fiber errors", func(t *testing.T) {
+		_, app := setupErrorApp()
+		app.Get("/teapot", func(c *fiber.Ctx) error {
+			return fiber.NewError(fiber.StatusTeapot, "short and stout")
+		})
+
+		status, _, problem := requestProblem(t, app, "/missing")
+		assert.Equal(t, fiber.StatusNotFound, status)
+		assert.Equal(t, "Not Found", problem["title"])
+		assert.Contains(t, problem["detail"], "Cannot GET /missing")
+
+		status, _, problem = requestProblem(t, app, "/teapot")
+		assert.Equal(t, fiber.StatusTeapot, status)
+		assert.Equal(t, "short and stout", problem["detail"])
+	})
+
+	t.Run("Hides details of unknown errors", func(t *testing.T) {
+		_, app := setupErrorApp()
+		app.Get("/", func(c *fiber.Ctx) error {
+			return errors.New("dial tcp 10.0.0.1:3306: connection refused")
+		})
+
+		status, _, problem := requestProblem(t, app, "/")
+		assert.Equal(t, fiber.StatusInternalServerError, status)
+		assert.Equal(t, "Internal Server Error", problem["title"])
+		assert.NotContains(t, problem, "detail")
+	})
+
+	t.Run("Applies enrichers in order", func(t *testing.T) {
+		core, app := setupErrorApp(func(c *fiber.Ctx, err error, problem *state.Problem) {
+			problem.Extensions["traceId"] = c.Get("X-Trace-Id", "none")
+		})
+		core.OnProblem(func(c *fiber.Ctx, err error, problem *state.Problem) {
+			var badRequest *state.BadRequestError
+			problem.Extensions["badRequest"] = errors.As(err, &badRequest)
+			problem.Extensions["previous"] = problem.Extensions["traceId"]
+		})
+		app.Get("/", func(c *fiber.Ctx) error {
+			return state.NewBadRequestError("bad")
+		})
+
+		_, _, problem := requestProblem(t, app, "/")
+		assert.Equal(t, "none", problem["traceId"])
+		assert.Equal(t, "none", problem["previous"])
+		assert.Equal(t, true, problem["badRequest"])
+	})
+}
+
+func TestRenderErrors(t *testing.T) {
+	t.Run("Renders once before the middleware of New reads the status", func(t *testing.T) {
+		level := zerolog.GlobalLevel()
+		t.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+		rendered := 0
+		app, err := New(Config{
+			DatabaseUri: "sqlite://:memory:",
+			LogLevel:    zerolog.Disabled,
+			FiberConfig: &fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+				rendered++
+				return fiber.DefaultErrorHandler(c, err)
+			}},
+			Metrics: &MetricsConfig{Registry: prometheus.NewRegistry()},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { app.Db.Close() })
+		require.NoError(t, app.Migrate(&Book{}))
+		app.RegisterResource(&Book{})
+
+		resp, err := app.Fiber.Test(httptest.NewRequest("GET", "/books/404", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, 1, rendered)
+		assert.Contains(t, scrape(t, app, DefaultMetricsPath), `gapi_http_requests_total{code="404",operation="get_item",resource="/books"} 1`)
+	})
+}
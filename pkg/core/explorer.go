@@ -0,0 +1,128 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"path/filepath"
+	textTemplate "text/template"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultExplorerPath is the endpoint of the API explorer when
+// ExplorerConfig.Path is empty.
+const DefaultExplorerPath = "/docs"
+
+// ExplorerConfig enables an interactive API explorer built on Swagger UI.
+// Its assets are embedded in the binary, so the page works offline.
+type ExplorerConfig struct {
+	Path string // Explorer page, defaults to DefaultExplorerPath
+}
+
+// explorerAssets lists the Swagger UI files served next to the page.
+var explorerAssets = map[string]bool{
+	"swagger-ui.css":                  true,
+	"swagger-ui-bundle.js":            true,
+	"swagger-ui-standalone-preset.js": true,
+	"index.css":                       true,
+	"favicon-16x16.png":               true,
+	"favicon-32x32.png":               true,
+	"oauth2-redirect.html":            true,
+}
+
+var explorerPage = template.Must(template.New("explorer").Parse(`<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="UTF-8">
+    <title>{{.Title}}</title>
+    <link rel="stylesheet" type="text/css" href="{{.Path}}/swagger-ui.css" />
+    <link rel="stylesheet" type="text/css" href="{{.Path}}/index.css" />
+    <link rel="icon" type="image/png" href="{{.Path}}/favicon-32x32.png" sizes="32x32" />
+    <link rel="icon" type="image/png" href="{{.Path}}/favicon-16x16.png" sizes="16x16" />
+  </head>
+  <body>
+    <div id="swagger-ui"></div>
+    <script src="{{.Path}}/swagger-ui-bundle.js" charset="UTF-8"></script>
+    <script src="{{.Path}}/swagger-ui-standalone-preset.js" charset="UTF-8"></script>
+    <script src="{{.Path}}/swagger-initializer.js" charset="UTF-8"></script>
+  </body>
+</html>
+`))
+
+// explorerInitializer configures Swagger UI with the generated document. It
+// is served as a script rather than inlined so that pages work under a
+// Content-Security-Policy without 'unsafe-inline'.
+var explorerInitializer = textTemplate.Must(textTemplate.New("initializer").Funcs(textTemplate.FuncMap{
+	"js": func(value string) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}).Parse(`window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: {{js .SpecURL}},
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout",
+    oauth2RedirectUrl: window.location.origin + {{js .Path}} + "/oauth2-redirect.html"
+  });
+};
+`))
+
+// registerExplorerRoutes serves the explorer:
+//   - GET {path}                         -> Explorer page
+//   - GET {path}/swagger-initializer.js  -> Swagger UI configuration
+//   - GET {path}/:asset                  -> Bundled Swagger UI assets
+func (a *App) registerExplorerRoutes() {
+	path := a.explorer.Path
+	if path == "" {
+		path = DefaultExplorerPath
+	}
+
+	a.log.Info().Str("path", path).Msg("Registering API explorer routes")
+
+	data := struct {
+		Title   string
+		Path    string
+		SpecURL string
+	}{
+		Title:   a.OpenAPI().Info.Title,
+		Path:    path,
+		SpecURL: a.openAPIPath() + ".json",
+	}
+
+	var page, initializer bytes.Buffer
+	if err := explorerPage.Execute(&page, data); err != nil {
+		panic(err)
+	}
+	if err := explorerInitializer.Execute(&initializer, data); err != nil {
+		panic(err)
+	}
+
+	a.Fiber.Get(path, func(c *fiber.Ctx) error {
+		c.Type("html", "utf-8")
+		return c.Send(page.Bytes())
+	})
+	a.Fiber.Get(path+"/swagger-initializer.js", func(c *fiber.Ctx) error {
+		c.Type("js", "utf-8")
+		return c.Send(initializer.Bytes())
+	})
+	a.Fiber.Get(path+"/:asset", func(c *fiber.Ctx) error {
+		asset := c.Params("asset")
+		if !explorerAssets[asset] {
+			return c.Next()
+		}
+
+		content, err := fs.ReadFile(swaggerFiles.FS, asset)
+		if err != nil {
+			return err
+		}
+		c.Type(filepath.Ext(asset))
+		return c.Send(content)
+	})
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requestExplorer(t *testing.T, app *App, target string) (int, string, string) {
+	resp, err := app.Fiber.Test(httptest.NewRequest("GET", target, nil))
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+}
+
+func TestExplorerRoutes(t *testing.T) {
+	t.Run("Serves the page with bundled assets", func(t *testing.T) {
+		app := setupDocsApp(nil, &ExplorerConfig{})
+
+		status, contentType, body := requestExplorer(t, app, "/docs")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "text/html; charset=utf-8", contentType)
+		assert.Contains(t, body, "<title>library</title>")
+		assert.Contains(t, body, `src="/docs/swagger-ui-bundle.js"`)
+		assert.NotContains(t, body, "https://")
+
+		status, contentType, body = requestExplorer(t, app, "/docs/swagger-ui-bundle.js")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Contains(t, contentType, "javascript")
+		assert.Contains(t, body, "SwaggerUIBundle")
+
+		status, contentType, _ = requestExplorer(t, app, "/docs/swagger-ui.css")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Contains(t, contentType, "text/css")
+	})
+
+	t.Run("Points Swagger UI at the generated document", func(t *testing.T) {
+		app := setupDocsApp(&OpenAPIConfig{Path: "/spec"}, &ExplorerConfig{Path: "/explorer"})
+
+		status, _, body := requestExplorer(t, app, "/explorer/swagger-initializer.js")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Contains(t, body, `url: "/spec.json"`)
+		assert.NotContains(t, body, "petstore")
+
+		status, _, _ = requestExplorer(t, app, "/spec.json")
+		assert.Equal(t, fiber.StatusOK, status)
+	})
+
+	t.Run("Enables the document on its own", func(t *testing.T) {
+		app := setupDocsApp(nil, &ExplorerConfig{})
+
+		status, _, _ := requestExplorer(t, app, "/openapi.json")
+		assert.Equal(t, fiber.StatusOK, status)
+	})
+
+	t.Run("Does not serve other files", func(t *testing.T) {
+		app := setupDocsApp(nil, &ExplorerConfig{})
+
+		status, _, _ := requestExplorer(t, app, "/docs/swagger-ui-bundle.js.map")
+		assert.Equal(t, fiber.StatusNotFound, status)
+	})
+
+	t.Run("Is protected by the API middleware", func(t *testing.T) {
+		auth := func(c *fiber.Ctx) error {
+			if c.Get("X-Api-Key") != "secret" {
+				return c.SendStatus(fiber.StatusUnauthorized)
+			}
+			return c.Next()
+		}
+		app := setupDocsApp(nil, &ExplorerConfig{}, auth)
+
+		for _, target := range []string{"/docs", "/docs/swagger-ui-bundle.js", "/openapi.json", "/books"} {
+			status, _, _ := requestExplorer(t, app, target)
+			assert.Equal(t, fiber.StatusUnauthorized, status, target)
+		}
+
+		req := httptest.NewRequest("GET", "/docs", nil)
+		req.Header.Set("X-Api-Key", "secret")
+		resp, err := app.Fiber.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+}
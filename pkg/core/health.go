@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/n3crone/gapi-platform/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultHealthCheckTimeout bounds a check whose HealthCheck.Timeout is unset.
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+// Probe identifies a health endpoint, named after its path.
+type Probe string
+
+const (
+	// ProbeLiveness tells whether the process works at all. A failure makes
+	// the orchestrator restart it, so dependencies are not checked by default.
+	ProbeLiveness Probe = "livez"
+	// ProbeReadiness tells whether the application can serve traffic.
+	ProbeReadiness Probe = "readyz"
+	// ProbeStartup tells whether the application finished starting.
+	ProbeStartup Probe = "startupz"
+)
+
+// HealthChecker reports the health of a component, returning nil when it is
+// healthy. Implementations should honor the context deadline.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthCheckerFunc adapts a function to the HealthChecker interface.
+type HealthCheckerFunc func(ctx context.Context) error
+
+// CheckHealth calls f(ctx).
+func (f HealthCheckerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// HealthCheck registers a named checker on one or more probes.
+type HealthCheck struct {
+	Name    string        // Unique name shown in probe output, e.g. "database"
+	Checker HealthChecker // Component check
+	Timeout time.Duration // Check deadline, defaults to DefaultHealthCheckTimeout
+	Probes  []Probe       // Probes running the check, defaults to readiness and startup
+}
+
+// healthResult is the outcome of a single check.
+type healthResult struct {
+	name string
+	err  error
+}
+
+// AddHealthCheck registers checks run by the probe endpoints. The database
+// is checked by default under the name "database".
+//
+// Example usage:
+//
+//	app.AddHealthCheck(core.HealthCheck{
+//		Name:    "cache",
+//		Timeout: time.Second,
+//		Checker: core.HealthCheckerFunc(func(ctx context.Context) error {
+//			return redis.Ping(ctx).Err()
+//		}),
+//	})
+func (a *App) AddHealthCheck(checks ...HealthCheck) {
+	for _, check := range checks {
+		if check.Timeout <= 0 {
+			check.Timeout = DefaultHealthCheckTimeout
+		}
+		if len(check.Probes) == 0 {
+			check.Probes = []Probe{ProbeReadiness, ProbeStartup}
+		}
+		a.healthChecks = append(a.healthChecks, check)
+	}
+}
+
+// RegisterHealthRoutes registers Kubernetes compatible probe endpoints:
+//   - GET /livez, /readyz, /startupz -> Runs the checks of the probe
+//   - GET /{probe}/{check}           -> Runs a single check
+//
+// Probes answer 200 with "ok" when all checks pass and 503 otherwise.
+// ?verbose lists every check and ?exclude=name skips one. Failure reasons
+// are logged but withheld from responses, as probes are usually public.
+func (a *App) RegisterHealthRoutes() {
+	a.log.Info().Msg("Registering health probe routes")
+	for _, probe := range []Probe{ProbeLiveness, ProbeReadiness, ProbeStartup} {
+		handler := a.probeHandler(probe)
+		a.Fiber.Get("/"+string(probe), handler)
+		a.Fiber.Get("/"+string(probe)+"/:check", handler)
+	}
+}
+
+// probeHandler runs the checks of a probe concurrently and renders the
+// results in the Kubernetes format:
+//
+//	[+]ping ok
+//	[-]database failed: reason withheld
+//	readyz check failed
+func (a *App) probeHandler(probe Probe) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		args := c.Context().QueryArgs()
+		excluded := make(map[string]bool)
+		for _, name := range args.PeekMulti("exclude") {
+			excluded[string(name)] = true
+		}
+
+		only := c.Params("check")
+		var checks []HealthCheck
+		for _, check := range a.healthChecks {
+			if (only == "" || check.Name == only) && !excluded[check.Name] && check.runsOn(probe) {
+				checks = append(checks, check)
+			}
+		}
+
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		c.Set(fiber.HeaderCacheControl, "no-store")
+
+		if only != "" && only != "ping" && len(checks) == 0 {
+			return c.Status(fiber.StatusNotFound).
+				SendString(fmt.Sprintf("no %s check named %q", probe, only))
+		}
+
+		results := runHealthChecks(c.UserContext(), checks)
+		if only == "" || only == "ping" {
+			// ping always passes and shows the server is responding
+			results = append([]healthResult{{name: "ping"}}, results...)
+		}
+
+		var output strings.Builder
+		failed := false
+		for _, result := range results {
+			if result.err == nil {
+				fmt.Fprintf(&output, "[+]%s ok\n", result.name)
+				continue
+			}
+			failed = true
+			fmt.Fprintf(&output, "[-]%s failed: reason withheld\n", result.name)
+			a.log.Warn().
+				Err(result.err).
+				Str("probe", string(probe)).
+				Str("check", result.name).
+				Msg("Health check failed")
+		}
+
+		if failed {
+			fmt.Fprintf(&output, "%s check failed", probe)
+			return c.Status(fiber.StatusServiceUnavailable).SendString(output.String())
+		}
+		if !args.Has("verbose") {
+			return c.SendString("ok")
+		}
+		fmt.Fprintf(&output, "%s check passed", probe)
+		return c.SendString(output.String())
+	}
+}
+
+// runsOn reports whether the check belongs to the probe.
+func (h HealthCheck) runsOn(probe Probe) bool {
+	for _, p := range h.Probes {
+		if p == probe {
+			return true
+		}
+	}
+	return false
+}
+
+// runHealthChecks runs the checks concurrently, each under its own deadline,
+// and returns the results in registration order. A checker ignoring its
+// context is abandoned once the deadline passes.
+func runHealthChecks(ctx context.Context, checks []HealthCheck) []healthResult {
+	results := make([]healthResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- check.Checker.CheckHealth(ctx) }()
+
+			var err error
+			select {
+			case err = <-done:
+			case <-ctx.Done():
+				err = fmt.Errorf("timed out after %s", check.Timeout)
+			}
+			results[i] = healthResult{name: check.Name, err: err}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// databaseChecker reports the database as unhealthy when it does not answer
+// a ping within the deadline of the check.
+type databaseChecker struct {
+	db database.DB
+}
+
+func (d databaseChecker) CheckHealth(ctx context.Context) error {
+	sqlDB, err := d.db.GetOrm().DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("db down: %w", err)
+	}
+	return nil
+}
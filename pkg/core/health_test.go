@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/n3crone/gapi-platform/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupHealthApp(checks ...HealthCheck) *App {
+	app := &App{log: zerolog.Nop()}
+	app.Fiber = fiber.New()
+	app.AddHealthCheck(checks...)
+	app.RegisterHealthRoutes()
+	return app
+}
+
+func passing(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func requestProbe(t *testing.T, app *App, target string) (int, string) {
+	resp, err := app.Fiber.Test(httptest.NewRequest("GET", target, nil), -1)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestProbes(t *testing.T) {
+	t.Run("Passes with terse output", func(t *testing.T) {
+		app := setupHealthApp(HealthCheck{Name: "cache", Checker: HealthCheckerFunc(passing)})
+
+		for _, target := range []string{"/livez", "/readyz", "/startupz"} {
+			status, body := requestProbe(t, app, target)
+			assert.Equal(t, fiber.StatusOK, status, target)
+			assert.Equal(t, "ok", body, target)
+		}
+	})
+
+	t.Run("Lists checks with verbose output", func(t *testing.T) {
+		app := setupHealthApp(HealthCheck{Name: "cache", Checker: HealthCheckerFunc(passing)})
+
+		status, body := requestProbe(t, app, "/readyz?verbose")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "[+]ping ok\n[+]cache ok\nreadyz check passed", body)
+	})
+
+	t.Run("Fails with 503 and withholds the reason", func(t *testing.T) {
+		app := setupHealthApp(
+			HealthCheck{Name: "cache", Checker: HealthCheckerFunc(passing)},
+			HealthCheck{Name: "broker", Checker: HealthCheckerFunc(failing)},
+		)
+
+		status, body := requestProbe(t, app, "/readyz")
+		assert.Equal(t, fiber.StatusServiceUnavailable, status)
+		assert.Equal(t, "[+]ping ok\n[+]cache ok\n[-]broker failed: reason withheld\nreadyz check failed", body)
+		assert.NotContains(t, body, "connection refused")
+	})
+
+	t.Run("Keeps dependencies out of liveness by default", func(t *testing.T) {
+		app := setupHealthApp(
+			HealthCheck{Name: "broker", Checker: HealthCheckerFunc(failing)},
+			HealthCheck{Name: "deadlock", Checker: HealthCheckerFunc(passing), Probes: []Probe{ProbeLiveness}},
+		)
+
+		status, body := requestProbe(t, app, "/livez?verbose")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "[+]ping ok\n[+]deadlock ok\nlivez check passed", body)
+	})
+
+	t.Run("Excludes checks", func(t *testing.T) {
+		app := setupHealthApp(HealthCheck{Name: "broker", Checker: HealthCheckerFunc(failing)})
+
+		status, _ := requestProbe(t, app, "/readyz?exclude=broker")
+		assert.Equal(t, fiber.StatusOK, status)
+	})
+
+	t.Run("Runs a single check", func(t *testing.T) {
+		app := setupHealthApp(
+			HealthCheck{Name: "cache", Checker: HealthCheckerFunc(passing)},
+			HealthCheck{Name: "broker", Checker: HealthCheckerFunc(failing)},
+		)
+
+		status, body := requestProbe(t, app, "/readyz/cache")
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "ok", body)
+
+		status, body = requestProbe(t, app, "/readyz/broker")
+		assert.Equal(t, fiber.StatusServiceUnavailable, status)
+		assert.Equal(t, "[-]broker failed: reason withheld\nreadyz check failed", body)
+
+		status, _ = requestProbe(t, app, "/readyz/unknown")
+		assert.Equal(t, fiber.StatusNotFound, status)
+	})
+
+	t.Run("Fails checks exceeding their timeout", func(t *testing.T) {
+		app := setupHealthApp(HealthCheck{
+			Name:    "slow",
+			Timeout: 20 * time.Millisecond,
+			Checker: HealthCheckerFunc(func(context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			}),
+		})
+
+		start := time.Now()
+		status, _ := requestProbe(t, app, "/readyz")
+		assert.Equal(t, fiber.StatusServiceUnavailable, status)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("Checks the database", func(t *testing.T) {
+		db, err := database.New("sqlite://:memory:", zerolog.Nop())
+		require.NoError(t, err)
+
+		app := setupHealthApp(HealthCheck{Name: "database", Checker: databaseChecker{db: db}})
+		status, _ := requestProbe(t, app, "/readyz")
+		assert.Equal(t, fiber.StatusOK, status)
+
+		require.NoError(t, db.Close())
+		status, body := requestProbe(t, app, "/startupz?verbose")
+		assert.Equal(t, fiber.StatusServiceUnavailable, status)
+		assert.Contains(t, body, "[-]database failed")
+	})
+
+	t.Run("Pings the database within the check deadline", func(t *testing.T) {
+		db, err := database.New("sqlite://:memory:", zerolog.Nop())
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		checker := databaseChecker{db: db}
+		require.NoError(t, checker.CheckHealth(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, checker.CheckHealth(ctx), context.Canceled)
+	})
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Defaults used by Run when the configuration leaves them unset
+const (
+	DefaultAddr            = ":3000"
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// ShutdownHook releases a resource when the application stops. The context
+// carries the shutdown deadline.
+type ShutdownHook func(ctx context.Context) error
+
+// OnShutdown registers hooks run by Run once the server stopped accepting
+// requests. Hooks run in reverse registration order, so a component
+// registered after its dependencies is stopped before them, and the
+// database is closed after all hooks.
+//
+// Example usage:
+//
+//	app.OnShutdown(func(ctx context.Context) error {
+//		return publisher.Flush(ctx)
+//	})
+func (a *App) OnShutdown(hooks ...ShutdownHook) {
+	a.shutdownHooks = append(a.shutdownHooks, hooks...)
+}
+
+// Run starts the server and blocks until ctx is done, SIGINT or SIGTERM is
+// received, or the server fails. It then shuts down gracefully:
+//   - Stops accepting connections and waits for in-flight requests
+//   - Runs the shutdown hooks in reverse registration order
+//   - Closes the database connection
+//
+// Draining and hooks share the ShutdownTimeout deadline. Every step runs even
+// if a previous one failed; their errors are joined in the returned error.
+//
+// Example usage:
+//
+//	if err := app.Run(context.Background()); err != nil {
+//		log.Fatal(err)
+//	}
+func (a *App) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	addr := a.addr
+	if addr == "" {
+		addr = DefaultAddr
+	}
+
+	a.log.Info().Str("addr", addr).Msg("Starting server")
+
+	// Binding before serving reports address errors right away and lets
+	// shutdown close the listener even if Serve has not picked it up yet
+	ln, err := net.Listen(a.Fiber.Config().Network, addr)
+	if err != nil {
+		a.log.Error().Err(err).Msg("Server failed")
+		return errors.Join(append([]error{fmt.Errorf("server: %w", err)}, a.shutdown(nil, nil)...)...)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- a.Fiber.Listener(ln)
+	}()
+
+	var errs []error
+	select {
+	case err := <-served:
+		// The server stopped on its own
+		served = nil
+		if err != nil {
+			a.log.Error().Err(err).Msg("Server failed")
+			errs = append(errs, fmt.Errorf("server: %w", err))
+		}
+	case <-ctx.Done():
+		a.log.Info().Msg("Shutdown signal received")
+	}
+
+	return errors.Join(append(errs, a.shutdown(ln, served)...)...)
+}
+
+// shutdown drains the server unless it already stopped, then releases the
+// application resources. served delivers the result of serving ln.
+func (a *App) shutdown(ln net.Listener, served <-chan error) []error {
+	timeout := a.shutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+
+	if served != nil {
+		a.log.Info().
+			Dur("timeout", timeout).
+			Msg("Draining in-flight requests")
+
+		if err := a.Fiber.ShutdownWithContext(ctx); err != nil {
+			a.log.Error().Err(err).Msg("Failed to drain in-flight requests")
+			errs = append(errs, fmt.Errorf("server shutdown: %w", err))
+		}
+		// Shutting down before Serve took the listener leaves it open
+		_ = ln.Close()
+		if err := <-served; err != nil {
+			errs = append(errs, fmt.Errorf("server: %w", err))
+		}
+	}
+
+	for i := len(a.shutdownHooks) - 1; i >= 0; i-- {
+		if err := a.shutdownHooks[i](ctx); err != nil {
+			a.log.Error().
+				Err(err).
+				Int("hook", i).
+				Msg("Shutdown hook failed")
+			errs = append(errs, fmt.Errorf("shutdown hook %d: %w", i, err))
+		}
+	}
+
+	if a.Db != nil {
+		if err := a.Db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("database: %w", err))
+		}
+	}
+
+	if len(errs) == 0 {
+		a.log.Info().Msg("Application stopped gracefully")
+	}
+	return errs
+}
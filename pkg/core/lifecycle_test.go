@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/n3crone/gapi-platform/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRunApp creates an application listening on a random port with a
+// route that takes delay to answer. The returned channel yields the base URL
+// once the server accepts connections.
+func setupRunApp(t *testing.T, delay, timeout time.Duration) (*App, <-chan string) {
+	db, err := database.New("sqlite://:memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	app := &App{
+		Db:              db,
+		log:             zerolog.Nop(),
+		addr:            "127.0.0.1:0",
+		shutdownTimeout: timeout,
+	}
+	app.Fiber = fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Fiber.Get("/slow", func(c *fiber.Ctx) error {
+		time.Sleep(delay)
+		return c.SendString("done")
+	})
+
+	listening := make(chan string, 1)
+	app.Fiber.Hooks().OnListen(func(data fiber.ListenData) error {
+		listening <- "http://" + data.Host + ":" + data.Port
+		return nil
+	})
+
+	return app, listening
+}
+
+func TestRun(t *testing.T) {
+	t.Run("Drains requests, runs hooks in reverse and closes the database", func(t *testing.T) {
+		app, listening := setupRunApp(t, 200*time.Millisecond, time.Second)
+
+		var order []int
+		app.OnShutdown(
+			func(context.Context) error { order = append(order, 1); return nil },
+			func(context.Context) error { order = append(order, 2); return nil },
+		)
+		app.OnShutdown(func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok, "hooks receive the shutdown deadline")
+			order = append(order, 3)
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan error, 1)
+		go func() { stopped <- app.Run(ctx) }()
+		url := <-listening
+
+		type result struct {
+			body string
+			err  error
+		}
+		responses := make(chan result, 1)
+		go func() {
+			resp, err := http.Get(url + "/slow")
+			if err != nil {
+				responses <- result{err: err}
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			responses <- result{body: string(body), err: err}
+		}()
+
+		// Stop while the request is in flight
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+
+		response := <-responses
+		require.NoError(t, response.err)
+		assert.Equal(t, "done", response.body)
+
+		require.NoError(t, <-stopped)
+		assert.Equal(t, []int{3, 2, 1}, order)
+		assert.Equal(t, "down", app.Db.Health()["status"])
+	})
+
+	t.Run("Reports requests outliving the deadline", func(t *testing.T) {
+		app, listening := setupRunApp(t, 500*time.Millisecond, 50*time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan error, 1)
+		go func() { stopped <- app.Run(ctx) }()
+		url := <-listening
+
+		go func() {
+			if resp, err := http.Get(url + "/slow"); err == nil {
+				resp.Body.Close()
+			}
+		}()
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+
+		err := <-stopped
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, "down", app.Db.Health()["status"], "the database is closed anyway")
+	})
+
+	t.Run("Joins hook errors and keeps going", func(t *testing.T) {
+		app, listening := setupRunApp(t, 0, time.Second)
+
+		first, second := errors.New("first"), errors.New("second")
+		var ran []string
+		app.OnShutdown(
+			func(context.Context) error { ran = append(ran, "first"); return first },
+			func(context.Context) error { ran = append(ran, "second"); return second },
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan error, 1)
+		go func() { stopped <- app.Run(ctx) }()
+		<-listening
+		cancel()
+
+		err := <-stopped
+		assert.ErrorIs(t, err, first)
+		assert.ErrorIs(t, err, second)
+		assert.Equal(t, []string{"second", "first"}, ran)
+	})
+
+	t.Run("Stops when cancelled before the server is up", func(t *testing.T) {
+		app, _ := setupRunApp(t, 0, time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		done := make(chan error, 1)
+		go func() { done <- app.Run(ctx) }()
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run did not return")
+		}
+	})
+
+	t.Run("Shuts down when the server fails to start", func(t *testing.T) {
+		app, _ := setupRunApp(t, 0, time.Second)
+		app.addr = "256.0.0.1:0"
+
+		hooked := false
+		app.OnShutdown(func(context.Context) error { hooked = true; return nil })
+
+		err := app.Run(context.Background())
+		assert.ErrorContains(t, err, "server")
+		assert.True(t, hooked)
+		assert.Equal(t, "down", app.Db.Health()["status"])
+	})
+}
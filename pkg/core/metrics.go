@@ -0,0 +1,113 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Defaults used when MetricsConfig leaves them unset
+const (
+	DefaultMetricsPath      = "/metrics"
+	DefaultMetricsNamespace = "gapi"
+)
+
+// MetricsConfig enables a Prometheus endpoint exposing request metrics per
+// resource and operation, the database pool statistics and the Go runtime
+// metrics.
+type MetricsConfig struct {
+	Path      string               // Metrics endpoint, defaults to DefaultMetricsPath
+	Namespace string               // Prefix of the request metrics, defaults to DefaultMetricsNamespace
+	Buckets   []float64            // Latency histogram buckets in seconds, defaults to prometheus.DefBuckets
+	Registry  *prometheus.Registry // Registry to expose, e.g. holding custom metrics; a new one by default
+}
+
+// metrics holds the request collectors.
+type metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// registerMetrics installs the metrics middleware and endpoint. The
+// middleware is global, so every resource is measured without wiring; only
+// requests handled by a resource operation are recorded, which keeps label
+// values bounded.
+func (a *App) registerMetrics(config MetricsConfig) {
+	if config.Path == "" {
+		config.Path = DefaultMetricsPath
+	}
+	if config.Namespace == "" {
+		config.Namespace = DefaultMetricsNamespace
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = prometheus.DefBuckets
+	}
+	registry := config.Registry
+	if registry == nil {
+		registry = prometheus.NewRegistry()
+	}
+
+	m := &metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of handled requests by resource, operation and status code.",
+		}, []string{"resource", "operation", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Request latency by resource and operation.",
+			Buckets:   config.Buckets,
+		}, []string{"resource", "operation"}),
+	}
+	registry.MustRegister(m.requests, m.duration)
+
+	// A caller-supplied registry may already collect the runtime metrics
+	for _, collector := range []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	} {
+		var registered prometheus.AlreadyRegisteredError
+		if err := registry.Register(collector); err != nil && !errors.As(err, &registered) {
+			a.log.Warn().Err(err).Msg("Failed to register runtime metrics")
+		}
+	}
+
+	if a.Db != nil {
+		if sqlDB, err := a.Db.GetOrm().DB(); err == nil {
+			registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, a.Db.GetOrm().Dialector.Name()))
+		}
+	}
+
+	a.log.Info().Str("path", config.Path).Msg("Registering metrics route")
+
+	a.Fiber.Use(m.middleware)
+	a.Fiber.Get(config.Path, adaptor.HTTPHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+}
+
+// middleware times the request and records it once the resource handler has
+// set the resource and operation in context. The recorded status code is the
+// one sent, as errors are rendered by the inner renderErrors middleware.
+func (m *metrics) middleware(c *fiber.Ctx) error {
+	start := time.Now()
+
+	err := c.Next()
+
+	resource, ok := c.Locals("resource").(string)
+	if !ok {
+		return err
+	}
+	operation, _ := c.Locals("operation").(string)
+
+	m.requests.WithLabelValues(resource, operation, strconv.Itoa(c.Response().StatusCode())).Inc()
+	m.duration.WithLabelValues(resource, operation).Observe(time.Since(start).Seconds())
+	return err
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/database"
+	"github.com/n3crone/gapi-platform/pkg/resource"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMetricsApp(t *testing.T, config MetricsConfig) *App {
+	db, err := database.New("sqlite://:memory:", zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.AutoMigrate(&Book{}))
+
+	logger := zerolog.Nop()
+	app := &App{
+		Db:  db,
+		log: logger,
+		rm:  resource.NewResourceManager(db.GetOrm(), &logger),
+	}
+	app.Fiber = fiber.New(fiber.Config{ErrorHandler: app.errorHandler})
+	app.registerMetrics(config)
+	app.Fiber.Use(renderErrors)
+	app.RegisterResource(&Book{})
+	return app
+}
+
+func scrape(t *testing.T, app *App, path string) string {
+	resp, err := app.Fiber.Test(httptest.NewRequest("GET", path, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Run("Records requests per resource and operation", func(t *testing.T) {
+		app := setupMetricsApp(t, MetricsConfig{})
+
+		req := httptest.NewRequest("POST", "/books", strings.NewReader(`{"title":"Dune"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Fiber.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		for _, target := range []string{"/books/1", "/books/1", "/books/404", "/unknown"} {
+			_, err := app.Fiber.Test(httptest.NewRequest("GET", target, nil))
+			require.NoError(t, err)
+		}
+
+		body := scrape(t, app, "/metrics")
+		assert.Contains(t, body, `gapi_http_requests_total{code="200",operation="create",resource="/books"} 1`)
+		assert.Contains(t, body, `gapi_http_requests_total{code="200",operation="get_item",resource="/books"} 2`)
+		assert.Contains(t, body, `gapi_http_requests_total{code="404",operation="get_item",resource="/books"} 1`)
+		assert.Contains(t, body, `gapi_http_request_duration_seconds_count{operation="get_item",resource="/books"} 3`)
+		assert.NotContains(t, body, `/unknown`, "only resource requests are recorded")
+	})
+
+	t.Run("Renders errors as problem details", func(t *testing.T) {
+		app := setupMetricsApp(t, MetricsConfig{})
+
+		resp, err := app.Fiber.Test(httptest.NewRequest("GET", "/books/404", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, ProblemContentType, resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("Exports the database pool", func(t *testing.T) {
+		app := setupMetricsApp(t, MetricsConfig{})
+
+		body := scrape(t, app, "/metrics")
+		assert.Contains(t, body, `go_sql_max_open_connections{db_name="sqlite"} 1`)
+		assert.Contains(t, body, `go_sql_in_use_connections{db_name="sqlite"}`)
+		assert.Contains(t, body, `go_sql_wait_duration_seconds_total{db_name="sqlite"}`)
+	})
+
+	t.Run("Shares a registry collecting the runtime metrics", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+		app := setupMetricsApp(t, MetricsConfig{Registry: registry})
+
+		_, err := app.Fiber.Test(httptest.NewRequest("GET", "/books", nil))
+		require.NoError(t, err)
+		body := scrape(t, app, "/metrics")
+		assert.Contains(t, body, `gapi_http_requests_total{code="200",operation="get_list",resource="/books"} 1`)
+		assert.Contains(t, body, "go_goroutines")
+	})
+
+	t.Run("Applies the configuration", func(t *testing.T) {
+		app := setupMetricsApp(t, MetricsConfig{Path: "/internal/metrics", Namespace: "library", Buckets: []float64{0.5}})
+
+		_, err := app.Fiber.Test(httptest.NewRequest("GET", "/books", nil))
+		require.NoError(t, err)
+
+		body := scrape(t, app, "/internal/metrics")
+		assert.Contains(t, body, `library_http_requests_total{code="200",operation="get_list",resource="/books"} 1`)
+		assert.Contains(t, body, `library_http_request_duration_seconds_bucket{operation="get_list",resource="/books",le="0.5"} 1`)
+	})
+}
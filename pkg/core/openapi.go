@@ -0,0 +1,106 @@
+package core
+
+import (
+	"github.com/n3crone/gapi-platform/pkg/openapi"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultOpenAPIPath is the endpoint of the OpenAPI document when
+// OpenAPIConfig.Path is empty.
+const DefaultOpenAPIPath = "/openapi"
+
+// YAMLContentType is the media type of the YAML OpenAPI document.
+const YAMLContentType = "application/yaml"
+
+// OpenAPIConfig enables the OpenAPI document describing registered resources.
+type OpenAPIConfig struct {
+	Path    string           // Document endpoint, defaults to DefaultOpenAPIPath
+	Info    openapi.Info     // API metadata, the title defaults to the Fiber app name
+	Servers []openapi.Server // Base URLs of the API
+}
+
+// OpenAPI builds the OpenAPI 3.1 document of the resources registered so far.
+func (a *App) OpenAPI() *openapi.Document {
+	info := openapi.Info{Version: "1.0.0"}
+	var servers []openapi.Server
+	if a.openAPI != nil {
+		info = a.openAPI.Info
+		servers = a.openAPI.Servers
+	}
+	if info.Title == "" {
+		info.Title = a.Fiber.Config().AppName
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+
+	doc := openapi.NewDocument(info)
+	doc.Servers = servers
+	for _, config := range a.resources {
+		doc.AddResource(config)
+	}
+	return doc
+}
+
+// openAPIPath returns the configured document endpoint.
+func (a *App) openAPIPath() string {
+	if a.openAPI == nil || a.openAPI.Path == "" {
+		return DefaultOpenAPIPath
+	}
+	return a.openAPI.Path
+}
+
+// registerDocumentationRoutes mounts the OpenAPI document and the explorer
+// when enabled. New runs it after installing the middleware, so that the
+// routes sit behind the same middleware as the API.
+func (a *App) registerDocumentationRoutes() {
+	// The explorer needs the document
+	if a.openAPI != nil || a.explorer != nil {
+		a.registerOpenAPIRoutes()
+	}
+	if a.explorer != nil {
+		a.registerExplorerRoutes()
+	}
+}
+
+// registerOpenAPIRoutes serves the document as JSON and YAML:
+//   - GET {path}.json -> JSON document
+//   - GET {path}.yaml -> YAML document
+//   - GET {path}      -> JSON or YAML depending on the Accept header
+//
+// The document is built on each request so that it always reflects every
+// registered resource.
+func (a *App) registerOpenAPIRoutes() {
+	path := a.openAPIPath()
+
+	a.log.Info().Str("path", path).Msg("Registering OpenAPI document routes")
+
+	a.Fiber.Get(path+".json", a.openAPIHandler(fiber.MIMEApplicationJSON))
+	a.Fiber.Get(path+".yaml", a.openAPIHandler(YAMLContentType))
+	a.Fiber.Get(path, func(c *fiber.Ctx) error {
+		format := c.Accepts(fiber.MIMEApplicationJSON, YAMLContentType, "application/x-yaml", "text/yaml")
+		if format == "" || format == fiber.MIMEApplicationJSON {
+			return a.openAPIHandler(fiber.MIMEApplicationJSON)(c)
+		}
+		return a.openAPIHandler(YAMLContentType)(c)
+	})
+}
+
+func (a *App) openAPIHandler(contentType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		doc := a.OpenAPI()
+
+		encode := doc.JSON
+		if contentType == YAMLContentType {
+			encode = doc.YAML
+		}
+		data, err := encode()
+		if err != nil {
+			return err
+		}
+
+		c.Set(fiber.HeaderContentType, contentType)
+		return c.Send(data)
+	}
+}
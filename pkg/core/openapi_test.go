@@ -0,0 +1,107 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/openapi"
+	"github.com/n3crone/gapi-platform/pkg/resource"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type Book struct {
+	ID    uint   `json:"id" gorm:"primaryKey"`
+	Title string `json:"title"`
+}
+
+func (b *Book) CreateResource(rm *resource.ResourceManager) *resource.Resource {
+	return rm.CreateResource(b)
+}
+
+func setupDocsApp(openAPI *OpenAPIConfig, explorer *ExplorerConfig, middleware ...fiber.Handler) *App {
+	app := &App{
+		log:      zerolog.Nop(),
+		rm:       resource.NewResourceManager(nil, nil),
+		openAPI:  openAPI,
+		explorer: explorer,
+	}
+	app.Fiber = fiber.New(fiber.Config{AppName: "library"})
+	for _, handler := range middleware {
+		app.Fiber.Use(handler)
+	}
+	app.registerDocumentationRoutes()
+	app.RegisterResource(&Book{})
+	return app
+}
+
+func requestDocument(t *testing.T, app *App, target, accept string) (string, []byte) {
+	req := httptest.NewRequest("GET", target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := app.Fiber.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.Header.Get("Content-Type"), body
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	t.Run("Serves the document as JSON", func(t *testing.T) {
+		app := setupDocsApp(&OpenAPIConfig{}, nil)
+
+		contentType, body := requestDocument(t, app, "/openapi.json", "")
+		assert.Equal(t, fiber.MIMEApplicationJSON, contentType)
+
+		var doc openapi.Document
+		require.NoError(t, json.Unmarshal(body, &doc))
+		assert.Equal(t, openapi.Version, doc.OpenAPI)
+		assert.Equal(t, "library", doc.Info.Title)
+		assert.Equal(t, "1.0.0", doc.Info.Version)
+		assert.Contains(t, doc.Paths, "/books/{id}")
+	})
+
+	t.Run("Serves the document as YAML", func(t *testing.T) {
+		app := setupDocsApp(&OpenAPIConfig{Path: "/docs/spec", Info: openapi.Info{Title: "Library API", Version: "2.0.0"}}, nil)
+
+		contentType, body := requestDocument(t, app, "/docs/spec.yaml", "")
+		assert.Equal(t, YAMLContentType, contentType)
+
+		var doc map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(body, &doc))
+		assert.Equal(t, map[string]interface{}{"title": "Library API", "version": "2.0.0"}, doc["info"])
+	})
+
+	t.Run("Is mounted by New without resources", func(t *testing.T) {
+		level := zerolog.GlobalLevel()
+		t.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+		app, err := New(Config{DatabaseUri: "sqlite://:memory:", LogLevel: zerolog.Disabled, OpenAPI: &OpenAPIConfig{}})
+		require.NoError(t, err)
+		t.Cleanup(func() { app.Db.Close() })
+
+		_, body := requestDocument(t, app, "/openapi.json", "")
+		var doc openapi.Document
+		require.NoError(t, json.Unmarshal(body, &doc))
+		assert.Equal(t, openapi.Version, doc.OpenAPI)
+		assert.Empty(t, doc.Paths)
+	})
+
+	t.Run("Negotiates the format", func(t *testing.T) {
+		app := setupDocsApp(&OpenAPIConfig{}, nil)
+
+		contentType, _ := requestDocument(t, app, "/openapi", "")
+		assert.Equal(t, fiber.MIMEApplicationJSON, contentType)
+
+		contentType, _ = requestDocument(t, app, "/openapi", "application/yaml")
+		assert.Equal(t, YAMLContentType, contentType)
+	})
+}
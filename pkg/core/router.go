@@ -3,15 +3,20 @@ package core
 import (
 	"fmt"
 
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/pkg/logging"
 	"github.com/n3crone/gapi-platform/pkg/resource"
+	"github.com/n3crone/gapi-platform/pkg/state"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
 )
 
 // RegisterResource registers a new API resource with the application.
 // It takes a Registrable resource interface and:
 // - Creates a new resource instance with the resource manager
 // - Registers all CRUD routes for the resource with the Fiber app
+// - Adds the resource to the OpenAPI document
 func (a *App) RegisterResource(resource resource.Registrable) {
 	resourceType := fmt.Sprintf("%T", resource)
 
@@ -43,14 +48,45 @@ func (a *App) RegisterResource(resource resource.Registrable) {
 		Msg("Resource created with configuration")
 
 	newResource.RegisterRoutes(a.Fiber)
+	a.resources = append(a.resources, config)
 
 	a.log.Info().
 		Str("resource_type", resourceType).
 		Msg("Resource routes registered successfully")
 }
 
-// RegisterHealthRoutes registers the core application health check route.
+// On registers hooks run on the event for every operation of every
+// resource, before the hooks of the resource and operation.
+//
+// Example usage:
+//
+//	app.On(state.EventPostWrite, func(c *fiber.Ctx, data interface{}) (interface{}, error) {
+//		audit.Record(c.Locals("operation"), data)
+//		return data, nil
+//	})
+func (a *App) On(event state.Event, hooks ...state.Hook) {
+	a.rm.On(event, hooks...)
+}
+
+// AddVoter registers voters taking part in the authorization of every
+// operation of every resource, see auth.Authorize.
+func (a *App) AddVoter(voters ...auth.Voter) {
+	a.rm.AddVoter(voters...)
+}
+
+// AddExtension registers query extensions scoping the provider queries of
+// every resource, e.g. tenant.NewExtension.
+func (a *App) AddExtension(extensions ...state.QueryExtension) {
+	a.rm.AddExtension(extensions...)
+}
+
+// RegisterHealthRoute registers the probe endpoints and the legacy /health
+// route reporting the database statistics.
+//
+// Deprecated: use RegisterHealthRoutes and the /livez, /readyz and
+// /startupz probes.
 func (s *App) RegisterHealthRoute() {
+	s.RegisterHealthRoutes()
 	s.log.Info().Msg("Registering core application routes")
 	s.Fiber.Get("/health", s.healthHandler)
 }
@@ -66,18 +102,25 @@ func getOperationNames(ops map[resource.Operation]*resource.OperationConfig) []s
 }
 
 // healthHandler is an HTTP handler that responds to health check requests.
+// It answers 503 when the database is down.
 func (s *App) healthHandler(c *fiber.Ctx) error {
-	s.log.Debug().
-		Str("ip", c.IP()).
-		Str("method", c.Method()).
-		Str("path", c.Path()).
-		Msg("Health check requested")
-
 	health := s.Db.Health()
 
-	s.log.Info().
+	s.requestLogger(c).Debug().
 		Interface("status", health).
 		Msg("Health check completed")
 
+	if health["status"] != "up" {
+		c.Status(fiber.StatusServiceUnavailable)
+	}
 	return c.JSON(health)
 }
+
+// requestLogger returns the logger of the request, tagged with its ID, or
+// the application logger when the request logging middleware did not run.
+func (a *App) requestLogger(c *fiber.Ctx) *zerolog.Logger {
+	if logging.RequestID(c) == "" {
+		return &a.log
+	}
+	return logging.Logger(c)
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"github.com/n3crone/gapi-platform/pkg/tracing"
+)
+
+// registerTracing installs the global tracer provider, the request
+// middleware and the GORM plugin. The provider is flushed and shut down by
+// Run after the other shutdown hooks.
+func (a *App) registerTracing(config tracing.Config, appName string) error {
+	if config.ServiceName == "" {
+		config.ServiceName = appName
+	}
+
+	provider, err := tracing.NewProvider(config)
+	if err != nil {
+		return err
+	}
+	tracing.Install(provider)
+	a.OnShutdown(provider.Shutdown)
+
+	if err := a.Db.GetOrm().Use(tracing.GormPlugin()); err != nil {
+		return err
+	}
+	a.Fiber.Use(tracing.Middleware())
+
+	a.log.Info().
+		Str("service_name", config.ServiceName).
+		Msg("Tracing enabled")
+	return nil
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/plugin/dbresolver"
+)
+
+// Pool defaults, used when neither the configuration nor the environment
+// sets a value
+const (
+	DefaultMaxOpenConns = 50
+	DefaultWaitCount    = 1000
+
+	// DefaultLoadFactor is the share of MaxOpenConns above which the
+	// database is reported as under heavy load.
+	DefaultLoadFactor = 0.8
+)
+
+// Environment variables read for settings left unset in Config
+const (
+	EnvMaxOpenConns    = "DB_MAX_OPEN_CONNS"     // e.g. 50, -1 for unlimited
+	EnvMaxIdleConns    = "DB_MAX_IDLE_CONNS"     // e.g. 10, -1 to keep none
+	EnvConnMaxLifetime = "DB_CONN_MAX_LIFETIME"  // e.g. 30m, -1 for unlimited
+	EnvConnMaxIdleTime = "DB_CONN_MAX_IDLE_TIME" // e.g. 5m, -1 for unlimited
+	EnvHealthMaxOpen   = "DB_HEALTH_MAX_OPEN_CONNS"
+	EnvHealthWaitCount = "DB_HEALTH_MAX_WAIT_COUNT"
+	EnvReplicas        = "DB_REPLICAS"       // Comma-separated replica URIs
+	EnvReplicaPolicy   = "DB_REPLICA_POLICY" // round_robin or random
+)
+
+// ReplicaPolicy selects the replica serving a read.
+type ReplicaPolicy string
+
+const (
+	ReplicaPolicyRoundRobin ReplicaPolicy = "round_robin" // Cycle through replicas, the default
+	ReplicaPolicyRandom     ReplicaPolicy = "random"      // Pick a replica at random
+)
+
+// Config holds the connection pool, read replica and health check settings.
+// Zero values are read from the environment, then fall back to defaults.
+type Config struct {
+	Pool   PoolConfig
+	Health HealthConfig
+
+	// Replicas lists read replica URIs, in the same formats as the primary.
+	// Reads are routed to them while writes always go to the primary, see
+	// state.DefaultProvider. Each replica gets its own pool sized by Pool.
+	Replicas      []string
+	ReplicaPolicy ReplicaPolicy // Defaults to ReplicaPolicyRoundRobin
+}
+
+// PoolConfig configures the database/sql connection pool. Negative values
+// disable the corresponding limit.
+type PoolConfig struct {
+	MaxOpenConns    int           // Maximum open connections, defaults to 50 (1 for SQLite)
+	MaxIdleConns    int           // Maximum idle connections, defaults to MaxOpenConns
+	ConnMaxLifetime time.Duration // Maximum connection age, unlimited by default
+	ConnMaxIdleTime time.Duration // Maximum time a connection stays idle, unlimited by default
+}
+
+// HealthConfig sets the thresholds above which Health reports degraded
+// performance.
+type HealthConfig struct {
+	MaxOpenConns int   // Open connections meaning heavy load, defaults to 80% of the pool limit
+	MaxWaitCount int64 // Connection waits meaning a bottleneck, defaults to 1000
+}
+
+// applyEnv fills unset fields from the environment.
+func (c *Config) applyEnv() error {
+	if err := envInt(EnvMaxOpenConns, &c.Pool.MaxOpenConns); err != nil {
+		return err
+	}
+	if err := envInt(EnvMaxIdleConns, &c.Pool.MaxIdleConns); err != nil {
+		return err
+	}
+	if err := envDuration(EnvConnMaxLifetime, &c.Pool.ConnMaxLifetime); err != nil {
+		return err
+	}
+	if err := envDuration(EnvConnMaxIdleTime, &c.Pool.ConnMaxIdleTime); err != nil {
+		return err
+	}
+	if err := envInt(EnvHealthMaxOpen, &c.Health.MaxOpenConns); err != nil {
+		return err
+	}
+
+	waitCount := int(c.Health.MaxWaitCount)
+	if err := envInt(EnvHealthWaitCount, &waitCount); err != nil {
+		return err
+	}
+	c.Health.MaxWaitCount = int64(waitCount)
+
+	if raw := os.Getenv(EnvReplicas); raw != "" && len(c.Replicas) == 0 {
+		for _, uri := range strings.Split(raw, ",") {
+			if uri = strings.TrimSpace(uri); uri != "" {
+				c.Replicas = append(c.Replicas, uri)
+			}
+		}
+	}
+	if c.ReplicaPolicy == "" {
+		c.ReplicaPolicy = ReplicaPolicy(os.Getenv(EnvReplicaPolicy))
+	}
+
+	return nil
+}
+
+// applyDefaults resolves the remaining zero values. The maximum of open
+// connections depends on the driver, the other settings derive from it.
+func (c *Config) applyDefaults(driver string) {
+	if c.Pool.MaxOpenConns == 0 {
+		c.Pool.MaxOpenConns = DefaultMaxOpenConns
+		// SQLite serializes writes, and every connection to :memory: opens
+		// a separate database, so a single connection keeps migrations visible
+		if driver == "sqlite" {
+			c.Pool.MaxOpenConns = 1
+		}
+	}
+	if c.Pool.MaxIdleConns == 0 {
+		c.Pool.MaxIdleConns = c.Pool.MaxOpenConns
+		if c.Pool.MaxOpenConns < 0 {
+			c.Pool.MaxIdleConns = DefaultMaxOpenConns
+		}
+	}
+	if c.Health.MaxOpenConns == 0 && c.Pool.MaxOpenConns > 0 {
+		c.Health.MaxOpenConns = int(float64(c.Pool.MaxOpenConns) * DefaultLoadFactor)
+	}
+	if c.Health.MaxWaitCount == 0 {
+		c.Health.MaxWaitCount = DefaultWaitCount
+	}
+	if c.ReplicaPolicy == "" {
+		c.ReplicaPolicy = ReplicaPolicyRoundRobin
+	}
+}
+
+// resolverPolicy maps the replica policy to its dbresolver implementation.
+// The strict round robin policy is used as it is safe for concurrent reads.
+func (c *Config) resolverPolicy() (dbresolver.Policy, error) {
+	switch c.ReplicaPolicy {
+	case ReplicaPolicyRoundRobin:
+		return dbresolver.StrictRoundRobinPolicy(), nil
+	case ReplicaPolicyRandom:
+		return dbresolver.RandomPolicy{}, nil
+	default:
+		return nil, fmt.Errorf("invalid replica policy %q: expected %q or %q",
+			c.ReplicaPolicy, ReplicaPolicyRoundRobin, ReplicaPolicyRandom)
+	}
+}
+
+func envInt(name string, target *int) error {
+	raw, ok := os.LookupEnv(name)
+	if !ok || raw == "" || *target != 0 {
+		return nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %q is not an integer", name, raw)
+	}
+	*target = value
+	return nil
+}
+
+func envDuration(name string, target *time.Duration) error {
+	raw, ok := os.LookupEnv(name)
+	if !ok || raw == "" || *target != 0 {
+		return nil
+	}
+
+	if raw == "-1" {
+		*target = -1
+		return nil
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %q is not a duration", name, raw)
+	}
+	*target = value
+	return nil
+}
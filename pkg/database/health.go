@@ -51,9 +51,11 @@ func (s *service) Health() map[string]string {
 	// Database is up, add more statistics
 	stats["status"] = "up"
 	stats["message"] = "It's healthy"
+	stats["driver"] = s.orm.Dialector.Name()
 
 	// Get database stats
 	dbStats := sqlDB.Stats()
+	stats["max_open_connections"] = strconv.Itoa(dbStats.MaxOpenConnections)
 	stats["open_connections"] = strconv.Itoa(dbStats.OpenConnections)
 	stats["in_use"] = strconv.Itoa(dbStats.InUse)
 	stats["idle"] = strconv.Itoa(dbStats.Idle)
@@ -73,18 +75,19 @@ func (s *service) Health() map[string]string {
 		Int64("max_lifetime_closed", dbStats.MaxLifetimeClosed).
 		Msg("Database connection pool statistics")
 
-	// Evaluate stats to provide a health message
-	if dbStats.OpenConnections > 40 {
+	// Evaluate stats against the configured thresholds to provide a health message
+	thresholds := s.config.Health
+	if thresholds.MaxOpenConns > 0 && dbStats.OpenConnections > thresholds.MaxOpenConns {
 		s.logger.Warn().
 			Int("open_connections", dbStats.OpenConnections).
-			Int("threshold", 40).
+			Int("threshold", thresholds.MaxOpenConns).
 			Msg("High number of open connections detected")
 		stats["message"] = "The database is experiencing heavy load."
 	}
-	if dbStats.WaitCount > 1000 {
+	if thresholds.MaxWaitCount > 0 && dbStats.WaitCount > thresholds.MaxWaitCount {
 		s.logger.Warn().
 			Int64("wait_count", dbStats.WaitCount).
-			Int("threshold", 1000).
+			Int64("threshold", thresholds.MaxWaitCount).
 			Msg("High number of connection wait events detected")
 		stats["message"] = "The database has a high number of wait events, indicating potential bottlenecks."
 	}
@@ -103,6 +106,24 @@ func (s *service) Health() map[string]string {
 		stats["message"] = "Many connections are being closed due to max lifetime, consider increasing max lifetime or revising the connection usage pattern."
 	}
 
+	if len(s.replicas) > 0 {
+		down := 0
+		for i, replica := range s.replicas {
+			if err := replica.PingContext(ctx); err != nil {
+				s.logger.Warn().
+					Err(err).
+					Int("replica", i).
+					Msg("Read replica ping failed during health check")
+				down++
+			}
+		}
+		stats["replicas"] = strconv.Itoa(len(s.replicas))
+		stats["replicas_down"] = strconv.Itoa(down)
+		if down > 0 {
+			stats["message"] = fmt.Sprintf("%d of %d read replicas are unreachable.", down, len(s.replicas))
+		}
+	}
+
 	s.logger.Info().
 		Str("status", stats["status"]).
 		Str("message", stats["message"]).
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// connDialector hands an already opened connection pool to dbresolver, so
+// that replicas are opened, sized and closed by the service like the primary.
+type connDialector struct {
+	gorm.Dialector
+	conn gorm.ConnPool
+}
+
+func (d connDialector) Initialize(db *gorm.DB) error {
+	db.ConnPool = d.conn
+	return nil
+}
+
+// openReplicas connects to the configured replicas and registers them on db.
+// Queries are then routed to a replica chosen by the policy unless they run
+// in a transaction or carry the dbresolver.Write clause.
+func openReplicas(db *gorm.DB, config Config) ([]*sql.DB, error) {
+	if len(config.Replicas) == 0 {
+		return nil, nil
+	}
+
+	policy, err := config.resolverPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pools      []*sql.DB
+		dialectors []gorm.Dialector
+	)
+	closeAll := func() {
+		for _, pool := range pools {
+			pool.Close()
+		}
+	}
+
+	for i, uri := range config.Replicas {
+		dialector, err := Dialector(uri)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("replica %d: %v", i, err)
+		}
+
+		replica, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to connect to replica %d: %v", i, err)
+		}
+
+		sqlDB, err := replica.DB()
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to get replica %d instance: %v", i, err)
+		}
+		sqlDB.SetMaxIdleConns(config.Pool.MaxIdleConns)
+		sqlDB.SetMaxOpenConns(config.Pool.MaxOpenConns)
+		sqlDB.SetConnMaxLifetime(config.Pool.ConnMaxLifetime)
+		sqlDB.SetConnMaxIdleTime(config.Pool.ConnMaxIdleTime)
+
+		pools = append(pools, sqlDB)
+		dialectors = append(dialectors, connDialector{Dialector: dialector, conn: sqlDB})
+	}
+
+	if err := db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   policy,
+	})); err != nil {
+		closeAll()
+		return nil, fmt.Errorf("failed to register replicas: %v", err)
+	}
+
+	return pools, nil
+}
+
+// closeReplicas closes every replica pool and joins the errors.
+func closeReplicas(replicas []*sql.DB) error {
+	var errs []error
+	for i, replica := range replicas {
+		if err := replica.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("replica %d: %v", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
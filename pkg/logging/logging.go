@@ -0,0 +1,121 @@
+// Package logging provides request-scoped loggers tagged with a request ID
+// and structured access logs.
+package logging
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/rs/zerolog"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds incoming request IDs, longer ones are replaced.
+const maxRequestIDLength = 128
+
+// Config configures the request logging middleware.
+type Config struct {
+	DisableAccessLog bool // Only set up request IDs and loggers, e.g. when a proxy logs requests
+}
+
+// Middleware assigns each request an ID, taken from a valid incoming
+// X-Request-ID header or generated, and echoes it in the response. It
+// stores a logger tagged with the ID, see Logger, and once the request is
+// handled writes an access log with its status, duration, resource and
+// operation. The logged status is the response status, so errors must be
+// rendered by an inner middleware, as core.New does, to be logged as sent;
+// unrendered errors are returned unchanged.
+func Middleware(logger zerolog.Logger, config ...Config) fiber.Handler {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		id := c.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = utils.UUIDv4()
+		}
+		c.Set(RequestIDHeader, id)
+
+		// The context ties events to the trace of the request, if any
+		requestLogger := logger.With().
+			Str("request_id", id).
+			Ctx(c.UserContext()).
+			Logger()
+		c.Locals("requestId", id)
+		c.Locals("logger", &requestLogger)
+		c.SetUserContext(requestLogger.WithContext(c.UserContext()))
+
+		err := c.Next()
+
+		if cfg.DisableAccessLog {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		event := requestLogger.Info()
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			event = requestLogger.Error()
+		case status >= fiber.StatusBadRequest:
+			event = requestLogger.Warn()
+		}
+
+		if resource, ok := c.Locals("resource").(string); ok {
+			event = event.Str("resource", resource)
+		}
+		if operation, ok := c.Locals("operation").(string); ok {
+			event = event.Str("operation", operation)
+		}
+		event.
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Int("status", status).
+			Dur("duration", time.Since(start)).
+			Int("bytes", len(c.Response().Body())).
+			Str("ip", c.IP()).
+			Str("user_agent", c.Get(fiber.HeaderUserAgent)).
+			Msg("Request handled")
+		return err
+	}
+}
+
+// Logger returns the request-scoped logger set by Middleware. Without the
+// middleware it falls back to the logger of the request's user context,
+// see zerolog.Ctx, which is disabled by default.
+//
+// Example usage in a provider:
+//
+//	logging.Logger(c).Debug().Str("id", c.Params("id")).Msg("Loading order")
+func Logger(c *fiber.Ctx) *zerolog.Logger {
+	if logger, ok := c.Locals("logger").(*zerolog.Logger); ok {
+		return logger
+	}
+	return zerolog.Ctx(c.UserContext())
+}
+
+// RequestID returns the ID of the request, or "" without Middleware.
+func RequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals("requestId").(string)
+	return id
+}
+
+// validRequestID accepts bounded IDs of printable ASCII characters, so that
+// clients cannot inject line breaks or oversized values into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
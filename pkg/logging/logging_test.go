@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupLoggedApp creates an app whose logs are written to the returned
+// buffer, one JSON object per line.
+func setupLoggedApp(config ...Config) (*fiber.App, *bytes.Buffer) {
+	var output bytes.Buffer
+	app := fiber.New()
+	app.Use(Middleware(zerolog.New(&output), config...))
+	app.Use(testutils.RenderErrors)
+
+	app.Get("/books/:id", func(c *fiber.Ctx) error {
+		c.Locals("resource", "/books")
+		c.Locals("operation", "get_item")
+		Logger(c).Info().Msg("Loading book")
+
+		switch c.Params("id") {
+		case "0":
+			return fiber.ErrNotFound
+		case "500":
+			return fiber.ErrInternalServerError
+		}
+		return c.SendString("Dune")
+	})
+	return app, &output
+}
+
+func logLines(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("Generates a request ID shared by all logs", func(t *testing.T) {
+		app, output := setupLoggedApp()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/books/1", nil))
+		require.NoError(t, err)
+		id := resp.Header.Get(RequestIDHeader)
+		assert.Len(t, id, 36)
+
+		lines := logLines(t, output)
+		require.Len(t, lines, 2)
+		assert.Equal(t, "Loading book", lines[0]["message"])
+		assert.Equal(t, id, lines[0]["request_id"])
+		assert.Equal(t, id, lines[1]["request_id"])
+	})
+
+	t.Run("Honours an incoming request ID", func(t *testing.T) {
+		app, output := setupLoggedApp()
+
+		req := httptest.NewRequest("GET", "/books/1", nil)
+		req.Header.Set(RequestIDHeader, "edge-42")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, "edge-42", resp.Header.Get(RequestIDHeader))
+		assert.Equal(t, "edge-42", logLines(t, output)[0]["request_id"])
+	})
+
+	t.Run("Replaces invalid request IDs", func(t *testing.T) {
+		for _, id := range []string{"with space", strings.Repeat("a", 129), "café"} {
+			app, _ := setupLoggedApp()
+
+			req := httptest.NewRequest("GET", "/books/1", nil)
+			req.Header.Set(RequestIDHeader, id)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.NotEqual(t, id, resp.Header.Get(RequestIDHeader))
+			assert.Len(t, resp.Header.Get(RequestIDHeader), 36)
+		}
+	})
+
+	t.Run("Writes structured access logs", func(t *testing.T) {
+		app, output := setupLoggedApp()
+
+		req := httptest.NewRequest("GET", "/books/1", nil)
+		req.Header.Set(fiber.HeaderUserAgent, "tests")
+		_, err := app.Test(req)
+		require.NoError(t, err)
+
+		access := logLines(t, output)[1]
+		assert.Equal(t, "Request handled", access["message"])
+		assert.Equal(t, "info", access["level"])
+		assert.Equal(t, "GET", access["method"])
+		assert.Equal(t, "/books/1", access["path"])
+		assert.Equal(t, float64(200), access["status"])
+		assert.Equal(t, "/books", access["resource"])
+		assert.Equal(t, "get_item", access["operation"])
+		assert.Equal(t, float64(4), access["bytes"])
+		assert.Equal(t, "tests", access["user_agent"])
+		assert.Contains(t, access, "duration")
+	})
+
+	t.Run("Logs the status of errors by severity", func(t *testing.T) {
+		tests := map[string]struct {
+			status int
+			level  string
+		}{
+			"/books/0":   {fiber.StatusNotFound, "warn"},
+			"/books/500": {fiber.StatusInternalServerError, "error"},
+		}
+
+		for target, expected := range tests {
+			app, output := setupLoggedApp()
+
+			resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+			require.NoError(t, err)
+			assert.Equal(t, expected.status, resp.StatusCode)
+
+			access := logLines(t, output)[1]
+			assert.Equal(t, float64(expected.status), access["status"], target)
+			assert.Equal(t, expected.level, access["level"], target)
+		}
+	})
+
+	t.Run("Can skip access logs", func(t *testing.T) {
+		app, output := setupLoggedApp(Config{DisableAccessLog: true})
+
+		_, err := app.Test(httptest.NewRequest("GET", "/books/1", nil))
+		require.NoError(t, err)
+		assert.Len(t, logLines(t, output), 1)
+	})
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("Is available from the user context", func(t *testing.T) {
+		app, output := setupLoggedApp()
+		app.Get("/context", func(c *fiber.Ctx) error {
+			zerolog.Ctx(c.UserContext()).Info().Msg("From context")
+			return c.SendString(RequestID(c))
+		})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/context", nil))
+		require.NoError(t, err)
+
+		lines := logLines(t, output)
+		assert.Equal(t, "From context", lines[0]["message"])
+		assert.Equal(t, resp.Header.Get(RequestIDHeader), lines[0]["request_id"])
+	})
+
+	t.Run("Is disabled without the middleware", func(t *testing.T) {
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			assert.Equal(t, zerolog.Disabled, Logger(c).GetLevel())
+			assert.Empty(t, RequestID(c))
+			return nil
+		})
+
+		_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+	})
+}
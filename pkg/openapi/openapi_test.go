@@ -0,0 +1,290 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/pkg/resource"
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+type Address struct {
+	Street string `json:"street"`
+}
+
+type Category struct {
+	ID     uint      `json:"id" gorm:"primaryKey"`
+	Name   string    `json:"name"`
+	Parent *Category `json:"parent"`
+}
+
+type Product struct {
+	gorm.Model
+	Name     string            `json:"name" gorm:"size:120;not null" groups:"read,write"`
+	Status   string            `json:"status" gorm:"default:'draft'" validate:"oneof=draft published" groups:"read"`
+	Email    string            `json:"email" validate:"required,email" groups:"read,write"`
+	Price    float64           `json:"price" groups:"read,write"`
+	Nickname *string           `json:"nickname,omitempty" groups:"read"`
+	Tags     []string          `json:"tags" groups:"read"`
+	Labels   map[string]string `json:"labels" groups:"read"`
+	Address  Address           `json:"address" groups:"read"`
+	Category *Category         `json:"category" groups:"read"`
+	Secret   string            `json:"-"`
+}
+
+func productDocument(t *testing.T, customize ...func(*resource.ResourceConfig)) *Document {
+	rm := resource.NewResourceManager(nil, nil)
+	r := rm.CreateResource(&Product{}, customize...)
+
+	doc := NewDocument(Info{Title: "Shop", Version: "1.0.0"})
+	doc.AddResource(r.Config())
+
+	// Documents must always encode
+	_, err := doc.JSON()
+	require.NoError(t, err)
+	return doc
+}
+
+func parameterNames(params []*Parameter) []string {
+	names := make([]string, 0, len(params))
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestAddResource(t *testing.T) {
+	t.Run("Describes enabled operations", func(t *testing.T) {
+		doc := productDocument(t, func(rc *resource.ResourceConfig) {
+			rc.Operations[resource.OperationDelete].Enabled = false
+		})
+
+		require.Contains(t, doc.Paths, "/products")
+		require.Contains(t, doc.Paths, "/products/{id}")
+
+		collection, item := doc.Paths["/products"], doc.Paths["/products/{id}"]
+		assert.Equal(t, "getProductCollection", collection.Get.OperationID)
+		assert.Equal(t, "createProduct", collection.Post.OperationID)
+		assert.Equal(t, "getProduct", item.Get.OperationID)
+		assert.Equal(t, "updateProduct", item.Put.OperationID)
+		assert.Equal(t, "patchProduct", item.Patch.OperationID)
+		assert.Nil(t, item.Delete)
+		assert.Equal(t, []Tag{{Name: "Product"}}, doc.Tags)
+	})
+
+	t.Run("Describes the id path parameter from the primary key", func(t *testing.T) {
+		doc := productDocument(t)
+
+		params := doc.Paths["/products/{id}"].Get.Parameters
+		require.Len(t, params, 1)
+		assert.Equal(t, "path", params[0].In)
+		assert.True(t, params[0].Required)
+		assert.Equal(t, "integer", params[0].Schema.Type)
+	})
+
+	t.Run("References problem responses", func(t *testing.T) {
+		doc := productDocument(t)
+
+		responses := doc.Paths["/products/{id}"].Patch.Responses
+		assert.Equal(t, "#/components/responses/UnsupportedMediaType", responses["415"].Ref)
+		assert.Equal(t, "#/components/responses/UnprocessableEntity", responses["422"].Ref)
+		assert.Contains(t, doc.Components.Responses, "UnsupportedMediaType")
+		assert.Equal(t, "#/components/schemas/ValidationProblem",
+			doc.Components.Responses["UnprocessableEntity"].Content[problemContentType].Schema.Ref)
+		assert.Contains(t, doc.Paths["/products/{id}"].Delete.Responses, "204")
+	})
+
+	t.Run("References authorization errors of secured operations", func(t *testing.T) {
+		doc := productDocument(t, func(rc *resource.ResourceConfig) {
+			rc.Operations[resource.OperationDelete].Security = &auth.Security{Roles: []string{"admin"}}
+		})
+
+		responses := doc.Paths["/products/{id}"].Delete.Responses
+		assert.Equal(t, "#/components/responses/Unauthorized", responses["401"].Ref)
+		assert.Equal(t, "#/components/responses/Forbidden", responses["403"].Ref)
+		assert.NotContains(t, doc.Paths["/products/{id}"].Get.Responses, "403")
+		assert.Contains(t, doc.Components.Responses, "Forbidden")
+	})
+
+	t.Run("Describes soft deletion", func(t *testing.T) {
+		doc := productDocument(t)
+		assert.NotContains(t, doc.Paths, "/products/{id}/restore", "restore needs soft deletion")
+		assert.NotContains(t, parameterNames(doc.Paths["/products"].Get.Parameters), "deleted")
+
+		doc = productDocument(t, func(rc *resource.ResourceConfig) {
+			rc.SoftDelete.Enabled = true
+			rc.Trash = &auth.Security{Roles: []string{"admin"}}
+		})
+
+		require.Contains(t, doc.Paths, "/products/{id}/restore")
+		restore := doc.Paths["/products/{id}/restore"].Post
+		assert.Equal(t, "restoreProduct", restore.OperationID)
+		assert.Contains(t, restore.Responses, "200")
+		assert.Contains(t, restore.Responses, "403", "restore is always secured")
+
+		list := doc.Paths["/products"].Get
+		params := list.Parameters
+		assert.Equal(t, "deleted", params[len(params)-1].Name)
+		assert.Equal(t, []interface{}{"exclude", "include", "only"}, params[len(params)-1].Schema.Enum)
+		assert.Contains(t, list.Responses, "403")
+	})
+
+	t.Run("Describes versioning headers", func(t *testing.T) {
+		doc := productDocument(t, func(rc *resource.ResourceConfig) {
+			rc.Versioning = state.VersionConfig{Field: "UpdatedAt", Required: true}
+		})
+
+		item := doc.Paths["/products/{id}"]
+		assert.Contains(t, item.Get.Responses["200"].Headers, "ETag")
+		for _, op := range []*Operation{item.Put, item.Patch, item.Delete} {
+			ifMatch := op.Parameters[len(op.Parameters)-1]
+			assert.Equal(t, "If-Match", ifMatch.Name)
+			assert.Equal(t, "header", ifMatch.In)
+			assert.True(t, ifMatch.Required)
+			assert.Equal(t, "#/components/responses/PreconditionFailed", op.Responses["412"].Ref)
+			assert.Contains(t, op.Responses, "428")
+		}
+
+		doc = productDocument(t)
+		assert.Empty(t, doc.Paths["/products/{id}"].Get.Responses["200"].Headers)
+		assert.NotContains(t, doc.Paths["/products/{id}"].Put.Responses, "412")
+	})
+
+	t.Run("Accepts patch documents", func(t *testing.T) {
+		doc := productDocument(t)
+
+		content := doc.Paths["/products/{id}"].Patch.RequestBody.Content
+		assert.Contains(t, content, state.MergePatchContentType)
+		assert.Equal(t, "#/components/schemas/JSONPatch", content[state.JSONPatchContentType].Schema.Ref)
+	})
+
+	t.Run("Skips resources without a model", func(t *testing.T) {
+		doc := NewDocument(Info{Title: "Shop", Version: "1.0.0"})
+		doc.AddResource(resource.ResourceConfig{Path: "/things"})
+		assert.Empty(t, doc.Paths)
+	})
+}
+
+func TestSchemas(t *testing.T) {
+	t.Run("Reflects json and gorm tags", func(t *testing.T) {
+		doc := productDocument(t)
+
+		product := doc.Components.Schemas["Product"]
+		require.NotNil(t, product)
+		assert.NotContains(t, product.Properties, "Secret")
+		assert.True(t, product.Properties["ID"].ReadOnly)
+		assert.True(t, product.Properties["CreatedAt"].ReadOnly)
+		assert.Equal(t, "date-time", product.Properties["CreatedAt"].Format)
+		assert.Equal(t, 120, *product.Properties["name"].MaxLength)
+		assert.Equal(t, "draft", product.Properties["status"].Default)
+		assert.Equal(t, []interface{}{"draft", "published"}, product.Properties["status"].Enum)
+		assert.Equal(t, "email", product.Properties["email"].Format)
+		assert.Equal(t, []string{"string", "null"}, product.Properties["nickname"].Type)
+		assert.Equal(t, "string", product.Properties["tags"].Items.Type)
+		assert.Equal(t, []string{"object", "null"}, product.Properties["labels"].Type)
+		assert.Equal(t, "#/components/schemas/Address", product.Properties["address"].Ref)
+		assert.ElementsMatch(t, []string{"name", "email"}, product.Required)
+	})
+
+	t.Run("References recursive structs", func(t *testing.T) {
+		doc := productDocument(t)
+
+		category := doc.Components.Schemas["Category"]
+		require.NotNil(t, category)
+		assert.Equal(t, "#/components/schemas/Category", category.Properties["parent"].OneOf[0].Ref)
+		assert.Equal(t, "null", category.Properties["parent"].OneOf[1].Type)
+	})
+
+	t.Run("Names components after serialization groups", func(t *testing.T) {
+		doc := productDocument(t, func(rc *resource.ResourceConfig) {
+			for _, op := range rc.Operations {
+				op.NormalizationGroups = []string{"read"}
+			}
+			rc.Operations[resource.OperationCreate].DenormalizationGroups = []string{"write"}
+		})
+
+		create := doc.Paths["/products"].Post
+		assert.Equal(t, "#/components/schemas/Product.write", create.RequestBody.Content[jsonContentType].Schema.Ref)
+		assert.Equal(t, "#/components/schemas/Product.read", create.Responses["200"].Content[jsonContentType].Schema.Ref)
+
+		write := doc.Components.Schemas["Product.write"]
+		assert.Len(t, write.Properties, 3)
+		assert.Contains(t, write.Properties, "price")
+
+		// Address is not group aware and keeps a single component
+		assert.Contains(t, doc.Components.Schemas, "Address")
+		assert.NotContains(t, doc.Components.Schemas, "Address.read")
+	})
+}
+
+func TestCollection(t *testing.T) {
+	t.Run("Describes offset pagination, order and filters", func(t *testing.T) {
+		doc := productDocument(t, func(rc *resource.ResourceConfig) {
+			rc.Pagination.MaxPageSize = 50
+			rc.Order.Fields = []string{"Name", "price"}
+			rc.Filters = []state.Filter{
+				{Field: "Name", Strategy: state.FilterPartial},
+				{Field: "Price", Strategy: state.FilterRange},
+				{Field: "Status", Strategy: state.FilterIn, Param: "state"},
+			}
+		})
+
+		op := doc.Paths["/products"].Get
+		assert.Equal(t, []string{
+			"page", "itemsPerPage", "offset", "limit",
+			"order[name]", "order[price]",
+			"name", "price[gt]", "price[gte]", "price[lt]", "price[lte]", "state[]",
+		}, parameterNames(op.Parameters))
+		assert.Equal(t, float64(50), *op.Parameters[1].Schema.Maximum)
+		assert.Equal(t, "number", op.Parameters[7].Schema.Type)
+
+		envelope := op.Responses["200"].Content[jsonContentType].Schema
+		assert.Contains(t, envelope.Properties, "totalItems")
+		assert.Equal(t, "#/components/schemas/Product", envelope.Properties["items"].Items.Ref)
+	})
+
+	t.Run("Describes cursor pagination", func(t *testing.T) {
+		doc := productDocument(t, func(rc *resource.ResourceConfig) {
+			rc.Pagination.Mode = state.PaginationModeCursor
+		})
+
+		op := doc.Paths["/products"].Get
+		assert.Equal(t, []string{"cursor", "itemsPerPage", "limit"}, parameterNames(op.Parameters))
+		assert.Contains(t, op.Responses["200"].Content[jsonContentType].Schema.Properties, "nextCursor")
+	})
+
+	t.Run("Returns a plain array without pagination", func(t *testing.T) {
+		doc := productDocument(t, func(rc *resource.ResourceConfig) {
+			rc.Pagination.Disabled = true
+		})
+
+		op := doc.Paths["/products"].Get
+		assert.Empty(t, op.Parameters)
+		assert.Equal(t, "array", op.Responses["200"].Content[jsonContentType].Schema.Type)
+	})
+}
+
+func TestEncoding(t *testing.T) {
+	doc := productDocument(t)
+
+	jsonData, err := doc.JSON()
+	require.NoError(t, err)
+	yamlData, err := doc.YAML()
+	require.NoError(t, err)
+
+	var fromJSON, fromYAML interface{}
+	require.NoError(t, json.Unmarshal(jsonData, &fromJSON))
+	require.NoError(t, yaml.Unmarshal(yamlData, &fromYAML))
+
+	// Round trip YAML through JSON so that number types compare equal
+	normalized, err := json.Marshal(fromYAML)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(jsonData), string(normalized))
+	assert.Contains(t, string(yamlData), "openapi: 3.1.0")
+}
@@ -0,0 +1,462 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/n3crone/gapi-platform/pkg/resource"
+	"github.com/n3crone/gapi-platform/pkg/serializer"
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm/schema"
+)
+
+// Content types of request and response bodies
+const (
+	jsonContentType    = fiber.MIMEApplicationJSON
+	problemContentType = "application/problem+json"
+)
+
+// Names of the shared error responses in components
+const (
+	responseBadRequest           = "BadRequest"
+	responseUnauthorized         = "Unauthorized"
+	responseForbidden            = "Forbidden"
+	responseNotFound             = "NotFound"
+	responseConflict             = "Conflict"
+	responsePreconditionFailed   = "PreconditionFailed"
+	responsePreconditionRequired = "PreconditionRequired"
+	responseUnsupportedMediaType = "UnsupportedMediaType"
+	responseUnprocessableEntity  = "UnprocessableEntity"
+	responseInternalServerError  = "InternalServerError"
+)
+
+// schemaCache is shared by gorm schema parsing of resource models.
+var schemaCache sync.Map
+
+// AddResource describes the enabled operations of a resource. Resources
+// without a model are skipped since nothing is known about their payloads.
+func (d *Document) AddResource(config resource.ResourceConfig) {
+	if config.Model == nil {
+		return
+	}
+
+	modelType := indirect(reflect.TypeOf(config.Model))
+	name := modelType.Name()
+	modelSchema, _ := schema.Parse(reflect.New(modelType).Interface(), &schemaCache, schema.NamingStrategy{})
+
+	r := &resourceDescriber{doc: d, config: config, modelType: modelType, modelSchema: modelSchema, name: name}
+	collectionPath := config.Path
+	itemPath := config.Path + "/{id}"
+
+	if op := r.operation(resource.OperationGetList); op != nil {
+		op.OperationID = "get" + name + "Collection"
+		op.Summary = "Retrieves the collection of " + name + " resources."
+		op.Parameters = r.collectionParameters()
+		op.Responses["200"] = r.contentResponse(name+" collection", r.collectionSchema(config.Operations[resource.OperationGetList]))
+		addErrors(op, responseBadRequest, responseInternalServerError)
+		if config.SoftDelete.Enabled && config.Trash != nil {
+			addErrors(op, responseUnauthorized, responseForbidden)
+		}
+		d.pathItem(collectionPath).Get = op
+	}
+
+	if op := r.operation(resource.OperationCreate); op != nil {
+		op.OperationID = "create" + name
+		op.Summary = "Creates a " + name + " resource."
+		op.RequestBody = r.requestBody(resource.OperationCreate, jsonContentType)
+		op.Responses["200"] = r.itemResponse(resource.OperationCreate, name+" resource created")
+		addErrors(op, responseBadRequest, responseConflict, responseUnprocessableEntity, responseInternalServerError)
+		d.pathItem(collectionPath).Post = op
+	}
+
+	if op := r.operation(resource.OperationGetItem); op != nil {
+		op.OperationID = "get" + name
+		op.Summary = "Retrieves a " + name + " resource."
+		op.Parameters = []*Parameter{r.idParameter()}
+		op.Responses["200"] = r.itemResponse(resource.OperationGetItem, name+" resource")
+		if config.Versioning.Field != "" {
+			op.Responses["200"].Headers = map[string]*Header{
+				"ETag": {Description: "Version of the resource, for If-Match", Schema: &Schema{Type: "string"}},
+			}
+		}
+		addErrors(op, responseBadRequest, responseNotFound, responseInternalServerError)
+		d.pathItem(itemPath).Get = op
+	}
+
+	if op := r.operation(resource.OperationUpdate); op != nil {
+		op.OperationID = "update" + name
+		op.Summary = "Replaces the " + name + " resource."
+		op.Parameters = []*Parameter{r.idParameter()}
+		op.RequestBody = r.requestBody(resource.OperationUpdate, jsonContentType)
+		op.Responses["200"] = r.itemResponse(resource.OperationUpdate, name+" resource updated")
+		addErrors(op, responseBadRequest, responseNotFound, responseConflict, responseUnprocessableEntity, responseInternalServerError)
+		r.addPrecondition(op)
+		d.pathItem(itemPath).Put = op
+	}
+
+	if op := r.operation(resource.OperationPatch); op != nil {
+		op.OperationID = "patch" + name
+		op.Summary = "Updates the " + name + " resource partially."
+		op.Parameters = []*Parameter{r.idParameter()}
+		op.RequestBody = r.requestBody(resource.OperationPatch, state.MergePatchContentType, jsonContentType)
+		op.RequestBody.Content[state.JSONPatchContentType] = &MediaType{Schema: d.jsonPatchSchema()}
+		op.Responses["200"] = r.itemResponse(resource.OperationPatch, name+" resource updated")
+		addErrors(op, responseBadRequest, responseNotFound, responseConflict, responseUnsupportedMediaType,
+			responseUnprocessableEntity, responseInternalServerError)
+		r.addPrecondition(op)
+		d.pathItem(itemPath).Patch = op
+	}
+
+	if op := r.operation(resource.OperationDelete); op != nil {
+		op.OperationID = "delete" + name
+		op.Summary = "Removes the " + name + " resource."
+		op.Parameters = []*Parameter{r.idParameter()}
+		op.Responses["204"] = &Response{Description: name + " resource deleted"}
+		addErrors(op, responseBadRequest, responseNotFound, responseConflict, responseInternalServerError)
+		r.addPrecondition(op)
+		d.pathItem(itemPath).Delete = op
+	}
+
+	if op := r.operation(resource.OperationRestore); op != nil && config.SoftDelete.Enabled {
+		op.OperationID = "restore" + name
+		op.Summary = "Restores the deleted " + name + " resource."
+		op.Parameters = []*Parameter{r.idParameter()}
+		op.Responses["200"] = r.itemResponse(resource.OperationRestore, name+" resource restored")
+		addErrors(op, responseBadRequest, responseUnauthorized, responseForbidden, responseNotFound, responseConflict, responseInternalServerError)
+		d.pathItem(itemPath + "/restore").Post = op
+	}
+
+	d.Tags = append(d.Tags, Tag{Name: name})
+}
+
+// resourceDescriber holds what is known about the resource being described.
+type resourceDescriber struct {
+	doc         *Document
+	config      resource.ResourceConfig
+	modelType   reflect.Type
+	modelSchema *schema.Schema // nil when GORM cannot parse the model
+	name        string
+}
+
+// operation returns a new operation when op is enabled, nil otherwise.
+// Secured operations document the authorization errors.
+func (r *resourceDescriber) operation(op resource.Operation) *Operation {
+	opConfig, ok := r.config.Operations[op]
+	if !ok || !opConfig.Enabled {
+		return nil
+	}
+	operation := &Operation{Tags: []string{r.name}, Responses: make(map[string]*Response)}
+	if opConfig.Security != nil {
+		addErrors(operation, responseUnauthorized, responseForbidden)
+	}
+	return operation
+}
+
+// addPrecondition documents the If-Match header of versioned resources.
+func (r *resourceDescriber) addPrecondition(op *Operation) {
+	versioning := r.config.Versioning
+	if versioning.Field == "" {
+		return
+	}
+
+	op.Parameters = append(op.Parameters, &Parameter{
+		Name:        "If-Match",
+		In:          "header",
+		Description: "ETag of the " + r.name + " resource the request applies to",
+		Required:    versioning.Required,
+		Schema:      &Schema{Type: "string"},
+	})
+	addErrors(op, responsePreconditionFailed)
+	if versioning.Required {
+		addErrors(op, responsePreconditionRequired)
+	}
+}
+
+func (d *Document) pathItem(path string) *PathItem {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	return item
+}
+
+// itemSchema references the model schema in the given groups.
+func (r *resourceDescriber) itemSchema(groups []string) *Schema {
+	return r.doc.typeSchema(r.modelType, groups)
+}
+
+func (r *resourceDescriber) itemResponse(op resource.Operation, description string) *Response {
+	return r.contentResponse(description, r.itemSchema(r.config.Operations[op].NormalizationGroups))
+}
+
+func (r *resourceDescriber) contentResponse(description string, s *Schema) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]*MediaType{jsonContentType: {Schema: s}},
+	}
+}
+
+// requestBody describes the writable fields of the model for the operation.
+func (r *resourceDescriber) requestBody(op resource.Operation, contentTypes ...string) *RequestBody {
+	s := r.itemSchema(r.config.Operations[op].DenormalizationGroups)
+
+	body := &RequestBody{Required: true, Content: make(map[string]*MediaType, len(contentTypes))}
+	for _, contentType := range contentTypes {
+		body.Content[contentType] = &MediaType{Schema: s}
+	}
+	return body
+}
+
+// collectionSchema describes the response of get_list according to the
+// pagination mode of the resource.
+func (r *resourceDescriber) collectionSchema(opConfig *resource.OperationConfig) *Schema {
+	items := &Schema{Type: "array", Items: r.itemSchema(opConfig.NormalizationGroups)}
+
+	pagination := r.config.Pagination
+	if pagination.Disabled {
+		return items
+	}
+
+	envelope := reflect.TypeOf(state.Collection{})
+	if pagination.Mode == state.PaginationModeCursor {
+		envelope = reflect.TypeOf(state.CursorCollection{})
+	}
+
+	s := r.doc.objectSchema(envelope, nil)
+	s.Properties["items"] = items
+	for name, property := range s.Properties {
+		if name == "next" || name == "previous" {
+			property.Format = "uri-reference"
+		}
+	}
+	return s
+}
+
+// idParameter describes the :id path parameter from the model primary key.
+func (r *resourceDescriber) idParameter() *Parameter {
+	s := &Schema{Type: "string"}
+	if r.modelSchema != nil && r.modelSchema.PrioritizedPrimaryField != nil {
+		s = r.doc.typeSchema(indirect(r.modelSchema.PrioritizedPrimaryField.FieldType), nil)
+	}
+	return &Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: r.name + " identifier",
+		Required:    true,
+		Schema:      s,
+	}
+}
+
+// collectionParameters lists the pagination, deletion state, order and
+// filter query parameters accepted by get_list.
+func (r *resourceDescriber) collectionParameters() []*Parameter {
+	var params []*Parameter
+
+	pagination := r.config.Pagination
+	if !pagination.Disabled {
+		defaultSize, maxSize := pagination.DefaultPageSize, pagination.MaxPageSize
+		if maxSize <= 0 {
+			maxSize = state.DefaultMaxPageSize
+		}
+		if defaultSize <= 0 {
+			defaultSize = state.DefaultPageSize
+		}
+		if defaultSize > maxSize {
+			defaultSize = maxSize
+		}
+		size := func(name, description string) *Parameter {
+			return queryParameter(name, description, &Schema{
+				Type: "integer", Default: defaultSize, Minimum: float(1), Maximum: float(float64(maxSize)),
+			})
+		}
+
+		if pagination.Mode == state.PaginationModeCursor {
+			params = append(params,
+				queryParameter("cursor", "Cursor of the page to retrieve, from nextCursor or prevCursor", &Schema{Type: "string"}),
+				size("itemsPerPage", "Number of items per page"),
+				size("limit", "Alias of itemsPerPage"),
+			)
+		} else {
+			params = append(params,
+				queryParameter("page", "Page number, starting at 1", &Schema{Type: "integer", Default: 1, Minimum: float(1)}),
+				size("itemsPerPage", "Number of items per page"),
+				queryParameter("offset", "Number of items to skip, used with limit instead of page", &Schema{Type: "integer", Minimum: float(0)}),
+				size("limit", "Number of items to return, used with offset instead of itemsPerPage"),
+			)
+		}
+	}
+
+	if softDelete := r.config.SoftDelete; softDelete.Enabled && r.config.Trash != nil {
+		param := softDelete.Param
+		if param == "" {
+			param = state.DefaultDeletedParam
+		}
+		params = append(params, queryParameter(param, "Deletion state of the listed records", &Schema{
+			Type:    "string",
+			Default: string(state.DeletedExclude),
+			Enum:    []interface{}{string(state.DeletedExclude), string(state.DeletedInclude), string(state.DeletedOnly)},
+		}))
+	}
+
+	if r.modelSchema == nil {
+		return params
+	}
+
+	for _, name := range r.config.Order.Fields {
+		field := r.modelSchema.LookUpField(name)
+		if field == nil {
+			continue
+		}
+		params = append(params, queryParameter("order["+serializer.JSONName(field)+"]", "Sort direction",
+			&Schema{Type: "string", Enum: []interface{}{"asc", "desc"}}))
+	}
+
+	for _, filter := range r.config.Filters {
+		field := r.modelSchema.LookUpField(filter.Field)
+		if field == nil {
+			continue
+		}
+		params = append(params, r.filterParameters(filter, field)...)
+	}
+
+	return params
+}
+
+// filterParameters describes the query parameters of a filter strategy.
+func (r *resourceDescriber) filterParameters(filter state.Filter, field *schema.Field) []*Parameter {
+	param := filter.Param
+	if param == "" {
+		param = serializer.JSONName(field)
+	}
+	value := func() *Schema { return r.doc.typeSchema(indirect(field.FieldType), nil) }
+
+	switch filter.Strategy {
+	case state.FilterExact:
+		return []*Parameter{queryParameter(param, "Exact match", value())}
+	case state.FilterPartial:
+		return []*Parameter{queryParameter(param, "Partial match", &Schema{Type: "string"})}
+	case state.FilterBoolean:
+		return []*Parameter{queryParameter(param, "Boolean match", &Schema{Type: "boolean"})}
+	case state.FilterNull:
+		return []*Parameter{queryParameter(param+"[null]", "Whether the value is null", &Schema{Type: "boolean"})}
+	case state.FilterIn:
+		explode := true
+		p := queryParameter(param+"[]", "Match any of the values, repeated or comma separated",
+			&Schema{Type: "array", Items: value()})
+		p.Explode = &explode
+		return []*Parameter{p}
+	case state.FilterRange:
+		return operatorParameters(param, value, "gt", "gte", "lt", "lte")
+	case state.FilterDate:
+		date := func() *Schema { return &Schema{Type: "string", Description: "Date or date-time"} }
+		return operatorParameters(param, date, "before", "strictly_before", "after", "strictly_after")
+	default:
+		return nil
+	}
+}
+
+func operatorParameters(param string, value func() *Schema, operators ...string) []*Parameter {
+	params := make([]*Parameter, 0, len(operators))
+	for _, operator := range operators {
+		params = append(params, queryParameter(param+"["+operator+"]", strings.ReplaceAll(operator, "_", " "), value()))
+	}
+	return params
+}
+
+func queryParameter(name, description string, s *Schema) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: s}
+}
+
+// addErrors references the shared problem responses of the operation.
+func addErrors(op *Operation, names ...string) {
+	for _, name := range names {
+		op.Responses[errorStatus[name]] = &Response{Ref: "#/components/responses/" + name}
+	}
+}
+
+var errorStatus = map[string]string{
+	responseBadRequest:           "400",
+	responseUnauthorized:         "401",
+	responseForbidden:            "403",
+	responseNotFound:             "404",
+	responseConflict:             "409",
+	responsePreconditionFailed:   "412",
+	responsePreconditionRequired: "428",
+	responseUnsupportedMediaType: "415",
+	responseUnprocessableEntity:  "422",
+	responseInternalServerError:  "500",
+}
+
+// addProblemComponents registers the RFC 7807 problem schemas and the shared
+// error responses referenced by operations.
+func (d *Document) addProblemComponents() {
+	d.Components.Schemas["Problem"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type":     {Type: "string", Format: "uri-reference", Description: "URI identifying the problem type"},
+			"title":    {Type: "string", Description: "Short summary of the problem type"},
+			"status":   {Type: "integer", Description: "HTTP status code"},
+			"detail":   {Type: "string", Description: "Explanation specific to this occurrence"},
+			"instance": {Type: "string", Format: "uri-reference", Description: "URI of the request that caused the problem"},
+		},
+		Required: []string{"type", "title", "status"},
+	}
+	d.Components.Schemas["ValidationProblem"] = &Schema{
+		AllOf: []*Schema{
+			{Ref: "#/components/schemas/Problem"},
+			{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"violations": {Type: "array", Items: d.typeSchema(reflect.TypeOf(state.Violation{}), nil)},
+				},
+			},
+		},
+	}
+
+	descriptions := map[string]string{
+		responseBadRequest:           "Invalid request",
+		responseUnauthorized:         "Authentication required",
+		responseForbidden:            "Access denied",
+		responseNotFound:             "Resource not found",
+		responseConflict:             "Conflict with the current state of the resource",
+		responsePreconditionFailed:   "The resource has been modified since its ETag was read",
+		responsePreconditionRequired: "If-Match header required",
+		responseUnsupportedMediaType: "Unsupported request content type",
+		responseUnprocessableEntity:  "Validation failed",
+		responseInternalServerError:  "Internal server error",
+	}
+	for name, description := range descriptions {
+		problem := "#/components/schemas/Problem"
+		if name == responseUnprocessableEntity {
+			problem = "#/components/schemas/ValidationProblem"
+		}
+		d.Components.Responses[name] = &Response{
+			Description: description,
+			Content:     map[string]*MediaType{problemContentType: {Schema: &Schema{Ref: problem}}},
+		}
+	}
+}
+
+// jsonPatchSchema registers the RFC 6902 JSON Patch document schema.
+func (d *Document) jsonPatchSchema() *Schema {
+	if _, exists := d.Components.Schemas["JSONPatch"]; !exists {
+		d.Components.Schemas["JSONPatch"] = &Schema{
+			Type: "array",
+			Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"op":    {Type: "string", Enum: []interface{}{"add", "remove", "replace", "move", "copy", "test"}},
+					"path":  {Type: "string", Description: "JSON pointer of the target location"},
+					"from":  {Type: "string", Description: "JSON pointer of the source location for move and copy"},
+					"value": {Description: "Value to add, replace or test"},
+				},
+				Required: []string{"op", "path"},
+			},
+		}
+	}
+	return &Schema{Ref: "#/components/schemas/JSONPatch"}
+}
@@ -0,0 +1,237 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/n3crone/gapi-platform/pkg/serializer"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	deletedAtType     = reflect.TypeOf(gorm.DeletedAt{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	// invalidNameChars matches characters not allowed in component names
+	invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+)
+
+// typeSchema returns the schema of a Go type as encoded by encoding/json.
+// Structs are registered as components, named after the type and the
+// serialization groups when they are group aware, and referenced.
+func (d *Document) typeSchema(t reflect.Type, groups []string) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	s := d.valueSchema(t, groups)
+	if nullable {
+		return nullableSchema(s)
+	}
+	return s
+}
+
+func (d *Document) valueSchema(t reflect.Type, groups []string) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == deletedAtType:
+		return &Schema{Type: []string{"string", "null"}, Format: "date-time"}
+	case implements(t, jsonMarshalerType):
+		// Encodes itself, the shape is unknown
+		return &Schema{}
+	case implements(t, textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32", Minimum: float(0)}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "int64", Minimum: float(0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return nullableSchema(&Schema{Type: "array", Items: d.typeSchema(t.Elem(), groups)})
+	case reflect.Array:
+		return &Schema{Type: "array", Items: d.typeSchema(t.Elem(), groups)}
+	case reflect.Map:
+		return nullableSchema(&Schema{Type: "object", AdditionalProperties: d.typeSchema(t.Elem(), groups)})
+	case reflect.Struct:
+		return &Schema{Ref: "#/components/schemas/" + d.structComponent(t, groups)}
+	default:
+		return &Schema{}
+	}
+}
+
+// structComponent registers the schema of a struct type and returns its
+// component name. Group unaware structs are serialized whole, so they get a
+// single component whatever the groups.
+func (d *Document) structComponent(t reflect.Type, groups []string) string {
+	name := invalidNameChars.ReplaceAllString(t.Name(), "_")
+	if name == "" {
+		name = "Object"
+	}
+	if groupAware(t) && len(groups) > 0 {
+		name += "." + invalidNameChars.ReplaceAllString(strings.Join(groups, "."), "_")
+	}
+
+	if _, exists := d.Components.Schemas[name]; exists {
+		return name
+	}
+
+	// Register before walking the fields so that recursive types terminate
+	s := &Schema{}
+	d.Components.Schemas[name] = s
+	*s = *d.objectSchema(t, groups)
+
+	return name
+}
+
+// objectSchema describes the JSON properties of a struct type in the groups.
+func (d *Document) objectSchema(t reflect.Type, groups []string) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for _, f := range serializer.Fields(t, groups) {
+		property, required := d.fieldSchema(f.StructField, groups)
+		s.Properties[f.Name] = property
+		if required {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+	return s
+}
+
+// fieldSchema returns the schema of a struct field, refined by its gorm and
+// validate tags, and whether the property is required.
+func (d *Document) fieldSchema(sf reflect.StructField, groups []string) (*Schema, bool) {
+	s := d.typeSchema(sf.Type, groups)
+	fieldType := indirect(sf.Type)
+
+	settings := schema.ParseTagSetting(sf.Tag.Get("gorm"), ";")
+	_, primaryKey := settings["PRIMARYKEY"]
+	_, primaryKeyAlias := settings["PRIMARY_KEY"]
+	_, autoCreate := settings["AUTOCREATETIME"]
+	_, autoUpdate := settings["AUTOUPDATETIME"]
+	_, notNull := settings["NOT NULL"]
+	defaultValue, hasDefault := settings["DEFAULT"]
+
+	// Keys and timestamps maintained by GORM cannot be written by clients
+	s.ReadOnly = primaryKey || primaryKeyAlias || autoCreate || autoUpdate || sf.Name == "ID" ||
+		(fieldType == timeType && (sf.Name == "CreatedAt" || sf.Name == "UpdatedAt")) ||
+		fieldType == deletedAtType
+
+	if size, err := strconv.Atoi(settings["SIZE"]); err == nil && fieldType.Kind() == reflect.String {
+		s.MaxLength = &size
+	}
+	if hasDefault {
+		s.Default = defaultFor(fieldType, strings.Trim(defaultValue, "'\""))
+	}
+	if comment := settings["COMMENT"]; comment != "" {
+		s.Description = comment
+	}
+
+	required := notNull && !hasDefault && !s.ReadOnly
+	for _, rule := range strings.Split(sf.Tag.Get("validate"), ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				s.Enum = append(s.Enum, value)
+			}
+		}
+	}
+
+	return s, required
+}
+
+// defaultFor converts a gorm default value to the field type. Database
+// expressions such as CURRENT_TIMESTAMP are left out.
+func defaultFor(t reflect.Type, value string) interface{} {
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case reflect.String:
+		return value
+	}
+	return nil
+}
+
+// nullableSchema allows null in addition to the given schema.
+func nullableSchema(s *Schema) *Schema {
+	switch typ := s.Type.(type) {
+	case string:
+		s.Type = []string{typ, "null"}
+		return s
+	case nil:
+		if s.Ref != "" {
+			return &Schema{OneOf: []*Schema{s, {Type: "null"}}}
+		}
+	}
+	return s
+}
+
+// groupAware reports whether any JSON field of the struct declares groups,
+// in which case the serializer filters its fields.
+func groupAware(t reflect.Type) bool {
+	for _, f := range serializer.Fields(t, nil) {
+		if _, ok := f.StructField.Tag.Lookup("groups"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func float(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,278 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/state"
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type concurrentModel struct {
+	ID   uint   `json:"id" gorm:"primarykey"`
+	Name string `json:"name"`
+}
+
+// concurrentRequest is a request sent by every worker with its expected
+// response.
+type concurrentRequest struct {
+	method     string
+	path       string
+	payload    string
+	wantStatus int
+	assertBody func(t *testing.T, body []byte)
+}
+
+var concurrentModels = map[string]interface{}{
+	"pointer model":     &concurrentModel{},
+	"non-pointer model": concurrentModel{},
+}
+
+// setupConcurrentResource creates a resource wired to the default provider and
+// processor backed by a mock database holding two distinct records.
+func setupConcurrentResource(t *testing.T, model interface{}) *fiber.App {
+	mockDB := &testutils.MockDB{
+		Records: []interface{}{
+			&concurrentModel{ID: 1, Name: "first"},
+			&concurrentModel{ID: 2, Name: "second"},
+		},
+	}
+
+	rm, _, _ := setupTestEnvironment(t)
+	resource := rm.CreateResource(model, func(rc *ResourceConfig) {
+		rc.Path = "/items"
+		for _, op := range rc.Operations {
+			op.Provider = &state.DefaultProvider{DB: mockDB}
+			op.Processor = &state.DefaultProcessor{DB: mockDB}
+		}
+	})
+
+	app := fiber.New()
+	resource.RegisterRoutes(app)
+	return app
+}
+
+// setupConcurrentDatabaseResource creates a resource wired to the default
+// provider and processor backed by a test database. Records 1 and 2
+// are only read, record 3 is updated and the following deletable records
+// are removed.
+func setupConcurrentDatabaseResource(t *testing.T, model interface{}, deletable int) *fiber.App {
+	db := testutils.NewTestDB(t, &concurrentModel{})
+	for _, name := range []string{"first", "second", "third"} {
+		require.NoError(t, db.Create(&concurrentModel{Name: name}).Error)
+	}
+	for i := 0; i < deletable; i++ {
+		require.NoError(t, db.Create(&concurrentModel{Name: "deletable"}).Error)
+	}
+
+	rm := NewResourceManager(db, nil)
+	resource := rm.CreateResource(model, func(rc *ResourceConfig) {
+		rc.Path = "/items"
+	})
+
+	app := fiber.New()
+	resource.RegisterRoutes(app)
+	return app
+}
+
+func TestConcurrentOperations(t *testing.T) {
+	const workers = 50
+
+	for name, model := range concurrentModels {
+		t.Run(name, func(t *testing.T) {
+			app := setupConcurrentResource(t, model)
+
+			requests := []concurrentRequest{
+				{
+					method:     http.MethodGet,
+					path:       "/items/1",
+					wantStatus: fiber.StatusOK,
+					assertBody: assertItem(1, "first"),
+				},
+				{
+					method:     http.MethodGet,
+					path:       "/items/2",
+					wantStatus: fiber.StatusOK,
+					assertBody: assertItem(2, "second"),
+				},
+				{
+					method:     http.MethodGet,
+					path:       "/items",
+					wantStatus: fiber.StatusOK,
+					assertBody: func(t *testing.T, body []byte) {
+						var result struct {
+							Items      []concurrentModel `json:"items"`
+							TotalItems int64             `json:"totalItems"`
+						}
+						require.NoError(t, json.Unmarshal(body, &result))
+						assert.Len(t, result.Items, 2)
+						assert.Equal(t, int64(2), result.TotalItems)
+					},
+				},
+				{
+					method:     http.MethodPost,
+					path:       "/items",
+					payload:    `{"name":"created"}`,
+					wantStatus: fiber.StatusOK,
+					assertBody: assertItem(1, "created"),
+				},
+				{
+					method:     http.MethodPut,
+					path:       "/items/2",
+					payload:    `{"name":"updated"}`,
+					wantStatus: fiber.StatusOK,
+					assertBody: assertItem(2, "updated"),
+				},
+				{
+					method:     http.MethodDelete,
+					path:       "/items/1",
+					wantStatus: fiber.StatusNoContent,
+				},
+			}
+
+			sendConcurrently(t, app, workers, func(int) []concurrentRequest { return requests })
+		})
+	}
+}
+
+func TestConcurrentDatabaseOperations(t *testing.T) {
+	const workers = 50
+
+	for name, model := range concurrentModels {
+		t.Run(name, func(t *testing.T) {
+			app := setupConcurrentDatabaseResource(t, model, workers)
+
+			requests := []concurrentRequest{
+				{
+					method:     http.MethodGet,
+					path:       "/items/1",
+					wantStatus: fiber.StatusOK,
+					assertBody: assertItem(1, "first"),
+				},
+				{
+					method:     http.MethodGet,
+					path:       "/items/2",
+					wantStatus: fiber.StatusOK,
+					assertBody: assertItem(2, "second"),
+				},
+				{
+					method:     http.MethodGet,
+					path:       "/items",
+					wantStatus: fiber.StatusOK,
+					assertBody: func(t *testing.T, body []byte) {
+						var result struct {
+							Items      []concurrentModel `json:"items"`
+							TotalItems int64             `json:"totalItems"`
+						}
+						require.NoError(t, json.Unmarshal(body, &result))
+						assert.GreaterOrEqual(t, result.TotalItems, int64(3))
+						assert.Equal(t, "first", result.Items[0].Name)
+					},
+				},
+				{
+					method:     http.MethodPost,
+					path:       "/items",
+					payload:    `{"name":"created"}`,
+					wantStatus: fiber.StatusOK,
+					assertBody: func(t *testing.T, body []byte) {
+						var result concurrentModel
+						require.NoError(t, json.Unmarshal(body, &result))
+						assert.Greater(t, result.ID, uint(3))
+						assert.Equal(t, "created", result.Name)
+					},
+				},
+				{
+					method:     http.MethodPut,
+					path:       "/items/3",
+					payload:    `{"name":"updated"}`,
+					wantStatus: fiber.StatusOK,
+					assertBody: assertItem(3, "updated"),
+				},
+			}
+
+			sendConcurrently(t, app, workers, func(worker int) []concurrentRequest {
+				return append(requests[:len(requests):len(requests)], concurrentRequest{
+					method:     http.MethodDelete,
+					path:       fmt.Sprintf("/items/%d", 4+worker),
+					wantStatus: fiber.StatusNoContent,
+				})
+			})
+		})
+	}
+}
+
+// sendConcurrently sends the requests of every worker at once and checks
+// their responses.
+func sendConcurrently(t *testing.T, app *fiber.App, workers int, requests func(worker int) []concurrentRequest) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		for _, r := range requests(i) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				var body io.Reader
+				if r.payload != "" {
+					body = bytes.NewBufferString(r.payload)
+				}
+				req := httptest.NewRequest(r.method, r.path, body)
+				if r.payload != "" {
+					req.Header.Set("Content-Type", "application/json")
+				}
+
+				resp, err := app.Test(req, -1)
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer resp.Body.Close()
+
+				assert.Equal(t, r.wantStatus, resp.StatusCode, "%s %s", r.method, r.path)
+				if r.assertBody != nil {
+					data, err := io.ReadAll(resp.Body)
+					assert.NoError(t, err)
+					r.assertBody(t, data)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestNewModel(t *testing.T) {
+	t.Run("Allocates distinct instances", func(t *testing.T) {
+		resource := &Resource{config: ResourceConfig{Model: &concurrentModel{ID: 7}}}
+
+		first := resource.newModel()
+		second := resource.newModel()
+
+		require.IsType(t, &concurrentModel{}, first)
+		assert.NotSame(t, first, second)
+		assert.NotSame(t, resource.config.Model, first)
+		assert.Zero(t, first.(*concurrentModel).ID)
+	})
+
+	t.Run("Returns nil without model", func(t *testing.T) {
+		resource := &Resource{}
+		assert.Nil(t, resource.newModel())
+	})
+}
+
+func assertItem(id uint, name string) func(t *testing.T, body []byte) {
+	return func(t *testing.T, body []byte) {
+		var result concurrentModel
+		if assert.NoError(t, json.Unmarshal(body, &result), fmt.Sprintf("body: %s", body)) {
+			assert.Equal(t, id, result.ID)
+			assert.Equal(t, name, result.Name)
+		}
+	}
+}
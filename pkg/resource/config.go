@@ -1,6 +1,11 @@
 package resource
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 // ResourceConfig defines the configuration for an API resource.
 // It specifies the data model, available operations, and base path
@@ -9,6 +14,14 @@ type ResourceConfig struct {
 	Model      interface{}                    // The data model struct for this resource
 	Operations map[Operation]*OperationConfig // Available CRUD operations and their configurations
 	Path       string                         // Base URL path for the resource
+	Pagination state.PaginationConfig         // Pagination settings for the get_list operation
+	Filters    []state.Filter                 // Query parameter filters available on the get_list operation
+	Order      state.OrderConfig              // Sortable fields and default order for the get_list operation
+	Hooks      state.Hooks                    // Hooks of every operation, run after the ResourceManager hooks
+	Extensions []state.QueryExtension         // Conditions of every provider query, applied after the ResourceManager extensions
+	SoftDelete state.SoftDeleteConfig         // Soft deletion settings, enabling the restore operation
+	Trash      *auth.Security                 // Who may list deleted records with the SoftDelete query parameter, nobody when nil
+	Versioning state.VersionConfig            // Optimistic concurrency control with ETag and If-Match headers
 }
 
 // Operation represents a CRUD operation type.
@@ -20,17 +33,38 @@ type Operation string
 const (
 	OperationCreate  Operation = "create"   // Create new resource instance (POST)
 	OperationUpdate  Operation = "update"   // Update existing resource (PUT)
+	OperationPatch   Operation = "patch"    // Partially update existing resource (PATCH)
 	OperationDelete  Operation = "delete"   // Delete resource instance (DELETE)
 	OperationGetItem Operation = "get_item" // Retrieve single resource (GET with ID)
 	OperationGetList Operation = "get_list" // Retrieve list of resources (GET)
+	OperationRestore Operation = "restore"  // Restore soft deleted resource (POST /restore), requires SoftDelete and defaults to the Trash or delete Security
 )
 
 // OperationConfig defines the behavior of a specific CRUD operation
 // by configuring its state management and processing pipeline.
 type OperationConfig struct {
-	Provider  StateProvider  // Responsible for fetching data from database
-	Processor StateProcessor // Handles state transformation and business logic
-	Enabled   bool           // Whether this operation is available
+	Provider              StateProvider  // Responsible for fetching data from database
+	Processor             StateProcessor // Handles state transformation and business logic
+	Enabled               bool           // Whether this operation is available
+	ValidationGroups      []string       // Constraint groups checked on input, defaults to "default" and the operation name, plus "update" for patch
+	NormalizationGroups   []string       // Serialization groups of output fields, all fields when empty
+	DenormalizationGroups []string       // Serialization groups of writable input fields, all fields when empty
+	Hooks                 state.Hooks    // Hooks of this operation, run after the resource hooks
+	Security              *auth.Security // Restricts who may perform the operation, everyone when nil
+}
+
+// validationGroups returns the configured validation groups or the default
+// group together with a group named after the operation, so that
+// `validate_create` constraints only apply on create. Patches produce the
+// updated record, they also check the `validate_update` constraints.
+func (oc *OperationConfig) validationGroups(op Operation) []string {
+	if len(oc.ValidationGroups) > 0 {
+		return oc.ValidationGroups
+	}
+	if op == OperationPatch {
+		return []string{state.DefaultValidationGroup, string(OperationUpdate), string(op)}
+	}
+	return []string{state.DefaultValidationGroup, string(op)}
 }
 
 // StateProvider defines the interface for preparing initial state
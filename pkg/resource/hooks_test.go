@@ -0,0 +1,162 @@
+package resource
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/state"
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type hookedNote struct {
+	ID     uint   `json:"id" gorm:"primarykey"`
+	Text   string `json:"text" validate:"required"`
+	Author string `json:"author"`
+}
+
+// recordEvents returns a hook appending label to events, keeping the data.
+func recordEvents(events *[]string, label string) state.Hook {
+	return func(_ *fiber.Ctx, data interface{}) (interface{}, error) {
+		*events = append(*events, label)
+		return data, nil
+	}
+}
+
+// setupHookedResource serves the note "first" written by "jane".
+func setupHookedResource(t *testing.T, configure func(rm *ResourceManager, rc *ResourceConfig)) (*fiber.App, *gorm.DB) {
+	return setupNotes(t, &hookedNote{}, configure, &hookedNote{Text: "first", Author: "jane"})
+}
+
+func TestHooks(t *testing.T) {
+	t.Run("Runs every event in pipeline order", func(t *testing.T) {
+		var events []string
+		app, _ := setupHookedResource(t, func(_ *ResourceManager, rc *ResourceConfig) {
+			for _, event := range []state.Event{
+				state.EventPreRead, state.EventPostRead, state.EventPreValidate,
+				state.EventPreWrite, state.EventPostWrite, state.EventPreSerialize,
+			} {
+				rc.Hooks.On(event, recordEvents(&events, string(event)))
+			}
+		})
+
+		status := testutils.Request(t, app, "PUT", "/notes/1", `{"text":"edited"}`).Status
+		require.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, []string{"pre_read", "post_read", "pre_validate", "pre_write", "post_write", "pre_serialize"}, events)
+
+		events = nil
+		status = testutils.Request(t, app, "GET", "/notes/1", "").Status
+		require.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, []string{"pre_read", "post_read", "pre_serialize"}, events)
+	})
+
+	t.Run("Runs manager, resource and operation hooks in order", func(t *testing.T) {
+		var events []string
+		app, _ := setupHookedResource(t, func(rm *ResourceManager, rc *ResourceConfig) {
+			rc.Operations[OperationCreate].Hooks.On(state.EventPostWrite, recordEvents(&events, "operation"))
+			rc.Hooks.On(state.EventPostWrite, recordEvents(&events, "resource"))
+			rm.On(state.EventPostWrite, recordEvents(&events, "manager"))
+		})
+
+		status := testutils.Request(t, app, "POST", "/notes", `{"text":"second"}`).Status
+		require.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, []string{"manager", "resource", "operation"}, events)
+
+		events = nil
+		status = testutils.Request(t, app, "PUT", "/notes/1", `{"text":"edited"}`).Status
+		require.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, []string{"manager", "resource"}, events, "operation hooks only run on their operation")
+	})
+
+	t.Run("Replaces data before validation", func(t *testing.T) {
+		app, db := setupHookedResource(t, func(_ *ResourceManager, rc *ResourceConfig) {
+			rc.Hooks.On(state.EventPreValidate, func(c *fiber.Ctx, data interface{}) (interface{}, error) {
+				note := data.(*hookedNote)
+				note.Author = c.Get("X-User")
+				if note.Text == "" {
+					note.Text = "untitled"
+				}
+				return note, nil
+			})
+		})
+
+		status := testutils.Request(t, app, "POST", "/notes", `{}`, "X-User", "john").Status
+		require.Equal(t, fiber.StatusOK, status)
+
+		var stored hookedNote
+		require.NoError(t, db.Last(&stored).Error)
+		assert.Equal(t, "untitled", stored.Text)
+		assert.Equal(t, "john", stored.Author)
+	})
+
+	t.Run("Short-circuits a write with an error", func(t *testing.T) {
+		app, db := setupHookedResource(t, func(_ *ResourceManager, rc *ResourceConfig) {
+			rc.Operations[OperationDelete].Hooks.On(state.EventPreWrite, func(*fiber.Ctx, interface{}) (interface{}, error) {
+				return nil, state.NewForbiddenError("notes are kept forever")
+			})
+		})
+
+		resp := testutils.Request(t, app, "DELETE", "/notes/1", "")
+		assert.Equal(t, fiber.StatusForbidden, resp.Status)
+		assert.Contains(t, resp.Body, "notes are kept forever")
+
+		var count int64
+		require.NoError(t, db.Model(&hookedNote{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Sees deleted records and keeps the empty response", func(t *testing.T) {
+		var deleted *hookedNote
+		app, _ := setupHookedResource(t, func(_ *ResourceManager, rc *ResourceConfig) {
+			rc.Hooks.On(state.EventPostWrite, func(_ *fiber.Ctx, data interface{}) (interface{}, error) {
+				deleted = data.(*hookedNote)
+				return data, nil
+			})
+		})
+
+		status := testutils.Request(t, app, "DELETE", "/notes/1", "").Status
+		assert.Equal(t, fiber.StatusNoContent, status)
+		require.NotNil(t, deleted)
+		assert.Equal(t, "first", deleted.Text)
+	})
+
+	t.Run("Skips the provider when pre_read supplies data", func(t *testing.T) {
+		app, _ := setupHookedResource(t, func(_ *ResourceManager, rc *ResourceConfig) {
+			rc.Operations[OperationGetItem].Hooks.On(state.EventPreRead, func(c *fiber.Ctx, data interface{}) (interface{}, error) {
+				if c.Params("id") == "42" {
+					return &hookedNote{ID: 42, Text: "cached"}, nil
+				}
+				return data, nil
+			})
+		})
+
+		resp := testutils.Request(t, app, "GET", "/notes/42", "")
+		require.Equal(t, fiber.StatusOK, resp.Status)
+		assert.JSONEq(t, `{"id":42,"text":"cached","author":""}`, resp.Body)
+
+		status := testutils.Request(t, app, "GET", "/notes/1", "").Status
+		assert.Equal(t, fiber.StatusOK, status, "other requests still reach the provider")
+	})
+
+	t.Run("Replaces the response before serialization", func(t *testing.T) {
+		app, _ := setupHookedResource(t, func(_ *ResourceManager, rc *ResourceConfig) {
+			rc.Operations[OperationGetItem].Hooks.On(state.EventPreSerialize, func(_ *fiber.Ctx, data interface{}) (interface{}, error) {
+				note := data.(*hookedNote)
+				return map[string]interface{}{"note": note, "words": len(strings.Fields(note.Text))}, nil
+			})
+		})
+
+		resp := testutils.Request(t, app, "GET", "/notes/1", "")
+		require.Equal(t, fiber.StatusOK, resp.Status)
+
+		var response map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &response))
+		assert.JSONEq(t, `1`, string(response["words"]))
+		assert.JSONEq(t, `{"id":1,"text":"first","author":"jane"}`, string(response["note"]))
+	})
+}
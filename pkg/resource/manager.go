@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/n3crone/gapi-platform/pkg/auth"
 	"github.com/n3crone/gapi-platform/pkg/state"
 
 	"github.com/rs/zerolog"
@@ -14,8 +15,11 @@ import (
 // It provides a centralized way to create and configure resources with
 // their associated CRUD operations.
 type ResourceManager struct {
-	DB     *gorm.DB
-	logger *zerolog.Logger
+	DB         *gorm.DB
+	logger     *zerolog.Logger
+	hooks      state.Hooks
+	voters     []auth.Voter
+	extensions []state.QueryExtension
 }
 
 // NewResourceManager creates a new instance of ResourceManager with the provided
@@ -31,6 +35,48 @@ func NewResourceManager(db *gorm.DB, logger *zerolog.Logger) *ResourceManager {
 	return &ResourceManager{DB: db, logger: logger}
 }
 
+// On registers hooks run on the event for every operation of every resource
+// created by the manager, before the resource and operation hooks.
+//
+// Example usage:
+//
+//	rm.On(state.EventPostWrite, func(c *fiber.Ctx, data interface{}) (interface{}, error) {
+//		if c.Locals("operation") == "delete" {
+//			audit.Record(c, data)
+//		}
+//		return data, nil
+//	})
+func (rm *ResourceManager) On(event state.Event, hooks ...state.Hook) {
+	rm.hooks.On(event, hooks...)
+}
+
+// AddVoter registers voters consulted on every operation of every resource
+// created by the manager, before the voters of the operation's Security.
+//
+// Example usage:
+//
+//	rm.AddVoter(auth.VoterFunc(func(c *fiber.Ctx, p *auth.Principal, op string, subject interface{}) auth.Vote {
+//		if order, ok := subject.(*Order); ok && p != nil && order.OwnerID == p.ID {
+//			return auth.VoteGrant
+//		}
+//		return auth.VoteAbstain
+//	}))
+func (rm *ResourceManager) AddVoter(voters ...auth.Voter) {
+	rm.voters = append(rm.voters, voters...)
+}
+
+// AddExtension registers query extensions scoping the provider queries of
+// every resource created by the manager, before the resource extensions.
+//
+// Example usage:
+//
+//	rm.AddExtension(tenant.NewExtension(tenant.Config{
+//		Resolvers: []tenant.Resolver{tenant.FromHeader("X-Tenant-ID")},
+//	}))
+func (rm *ResourceManager) AddExtension(extensions ...state.QueryExtension) {
+	rm.extensions = append(rm.extensions, extensions...)
+}
+
 // CreateResource creates a new API resource with the given model and optional
 // custom configurations. It automatically sets up default CRUD operations
 // and allows customization through functional options.
@@ -53,7 +99,8 @@ func (rm *ResourceManager) CreateResource(model interface{}, customConfig ...fun
 	}
 	defaultPath := "/" + strings.ToLower(modelType.Name()) + "s"
 
-	// Initialize default resource configuration with all CRUD operations
+	// Initialize default resource configuration with all CRUD operations,
+	// restore only being routed once soft deletion is enabled
 	config := ResourceConfig{
 		Model: model,
 		Path:  defaultPath,
@@ -68,6 +115,11 @@ func (rm *ResourceManager) CreateResource(model interface{}, customConfig ...fun
 				Processor: &state.DefaultProcessor{DB: rm.DB},
 				Enabled:   true,
 			},
+			OperationPatch: {
+				Provider:  &state.DefaultProvider{DB: rm.DB},
+				Processor: &state.DefaultProcessor{DB: rm.DB},
+				Enabled:   true,
+			},
 			OperationGetItem: {
 				Provider:  &state.DefaultProvider{DB: rm.DB},
 				Processor: &state.DefaultProcessor{DB: rm.DB},
@@ -83,6 +135,11 @@ func (rm *ResourceManager) CreateResource(model interface{}, customConfig ...fun
 				Processor: &state.DefaultProcessor{DB: rm.DB},
 				Enabled:   true,
 			},
+			OperationRestore: {
+				Provider:  &state.DefaultProvider{DB: rm.DB},
+				Processor: &state.DefaultProcessor{DB: rm.DB},
+				Enabled:   true,
+			},
 		},
 	}
 
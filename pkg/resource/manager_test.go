@@ -79,6 +79,7 @@ func TestCreateResource(t *testing.T) {
 		operations := []Operation{
 			OperationCreate,
 			OperationUpdate,
+			OperationPatch,
 			OperationGetItem,
 			OperationGetList,
 			OperationDelete,
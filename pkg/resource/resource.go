@@ -1,7 +1,17 @@
 package resource
 
 import (
+	"fmt"
+	"reflect"
+
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/pkg/serializer"
+	"github.com/n3crone/gapi-platform/pkg/state"
+	"github.com/n3crone/gapi-platform/pkg/tracing"
+
 	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // Resource represents a RESTful API resource that can be registered with
@@ -21,12 +31,21 @@ type Resource struct {
 // The following routes are registered if enabled in the configuration:
 // - POST   /{path}      -> Create operation
 // - PUT    /{path}/:id  -> Update operation
+// - PATCH  /{path}/:id  -> Patch operation
 // - DELETE /{path}/:id  -> Delete operation
 // - GET    /{path}/:id  -> Get item operation
 // - GET    /{path}      -> Get list operation
+// - POST   /{path}/:id/restore -> Restore operation, with soft deletion enabled
+//
+// It panics when the configuration cannot serve requests, e.g. cursor
+// pagination without a CursorSecret, so that the mistake shows at startup.
 func (r *Resource) RegisterRoutes(router fiber.Router) {
 	path := r.config.Path
 
+	if err := r.config.Pagination.Validate(); err != nil {
+		panic("resource " + path + ": " + err.Error())
+	}
+
 	if op, exists := r.config.Operations[OperationGetList]; exists && op.Enabled {
 		router.Get(path, r.handleOperation(OperationGetList))
 	}
@@ -43,18 +62,32 @@ func (r *Resource) RegisterRoutes(router fiber.Router) {
 		router.Put(path+"/:id", r.handleOperation(OperationUpdate))
 	}
 
+	if op, exists := r.config.Operations[OperationPatch]; exists && op.Enabled {
+		router.Patch(path+"/:id", r.handleOperation(OperationPatch))
+	}
+
 	if op, exists := r.config.Operations[OperationDelete]; exists && op.Enabled {
 		router.Delete(path+"/:id", r.handleOperation(OperationDelete))
 	}
+
+	if op, exists := r.config.Operations[OperationRestore]; exists && op.Enabled && r.config.SoftDelete.Enabled {
+		router.Post(path+"/:id/restore", r.handleOperation(OperationRestore))
+	}
 }
 
 // handleOperation creates a Fiber handler function for the specified operation.
 // It implements the standard request processing pipeline:
 // 1. Validates operation availability
-// 2. Sets model context
-// 3. Gets initial state from Provider
+// 2. Sets a fresh model instance, operation, hooks, query extensions and collection settings in context
+// 3. Gets initial state from Provider, around the pre_read and post_read hooks
 // 4. Processes state with Processor
-// 5. Returns result to client
+// 5. Returns result to client after the pre_serialize hooks, restricted to the normalization groups
+//
+// Operations are authorized against their Security and the voters, before
+// step 3 for collection operations and after it, with the loaded record,
+// for item operations. Listing deleted records also requires the Trash
+// security of the resource. Steps 3 to 5 are traced as the provide, process
+// and serialize spans.
 //
 // Parameters:
 //   - op: The Operation type to handle (create, update, delete, etc.)
@@ -64,35 +97,191 @@ func (r *Resource) RegisterRoutes(router fiber.Router) {
 //
 // Error Handling:
 //   - Returns 404 if operation is not found or disabled
+//   - Returns 401 or 403 if the caller may not perform the operation
 //   - Returns 204 if operation succeeds but has no content
 //   - Returns provider/processor errors as-is
 func (r *Resource) handleOperation(op Operation) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		operationConfig, exists := r.config.Operations[op]
 		if !exists || !operationConfig.Enabled {
-			return fiber.NewError(fiber.StatusNotFound, "Operation not found")
+			return state.NewNotFoundError("operation not found")
 		}
 
-		// Set model in context
-		c.Locals("model", r.config.Model)
+		// Set a per-request model instance in context so that providers and
+		// processors never share mutable state across goroutines
+		c.Locals("model", r.newModel())
+		c.Locals("resource", r.config.Path)
+		c.Locals("operation", string(op))
+		c.Locals("validationGroups", operationConfig.validationGroups(op))
+		c.Locals("denormalizationGroups", operationConfig.DenormalizationGroups)
+		c.Locals("pagination", r.config.Pagination)
+		c.Locals("filters", r.config.Filters)
+		c.Locals("order", r.config.Order)
+		c.Locals("softDelete", r.config.SoftDelete)
+		c.Locals("versioning", r.config.Versioning)
+
+		c.Locals("hooks", r.hooks(operationConfig))
+		c.Locals("queryExtensions", r.extensions())
+
+		// Collection operations have no subject, deny them before any query
+		collection := op == OperationGetList || op == OperationCreate
+		if collection {
+			if err := r.authorize(c, op, operationConfig, nil); err != nil {
+				return err
+			}
+		}
+		if op == OperationGetList {
+			if err := r.authorizeTrash(c); err != nil {
+				return err
+			}
+		}
 
-		// Get data from provider
-		data, err := operationConfig.Provider.Provide(c)
+		// Get data from provider, unless a pre_read hook supplied it
+		var data interface{}
+		err := r.stage(c, op, "provide", operationConfig.Provider, func() (err error) {
+			if data, err = state.Dispatch(c, state.EventPreRead, nil); err != nil || data != nil {
+				return err
+			}
+			if data, err = operationConfig.Provider.Provide(c); err != nil {
+				return err
+			}
+			data, err = state.Dispatch(c, state.EventPostRead, data)
+			return err
+		})
 		if err != nil {
 			return err
 		}
 
+		if !collection {
+			if err := r.authorize(c, op, operationConfig, data); err != nil {
+				return err
+			}
+		}
+
 		// Process data
-		result, err := operationConfig.Processor.Process(c, data)
+		var result interface{}
+		err = r.stage(c, op, "process", operationConfig.Processor, func() (err error) {
+			result, err = operationConfig.Processor.Process(c, data)
+			return err
+		})
 		if err != nil {
 			return err
 		}
 
-		if result == nil {
-			return c.SendStatus(fiber.StatusNoContent)
-		}
-		return c.JSON(result)
+		return r.stage(c, op, "serialize", nil, func() (err error) {
+			if result, err = state.Dispatch(c, state.EventPreSerialize, result); err != nil {
+				return err
+			}
+			if result == nil {
+				return c.SendStatus(fiber.StatusNoContent)
+			}
+			return c.JSON(serializer.Normalize(result, operationConfig.NormalizationGroups))
+		})
+	}
+}
+
+// stage runs a pipeline step in its own span. The span context is the
+// request's user context meanwhile, so that queries issued by the step
+// become children of the span.
+func (r *Resource) stage(c *fiber.Ctx, op Operation, name string, implementation interface{}, step func() error) error {
+	parent := c.UserContext()
+	ctx, span := tracing.Tracer().Start(parent, name)
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("gapi.resource", r.config.Path),
+		attribute.String("gapi.operation", string(op)),
+	)
+	if implementation != nil {
+		span.SetAttributes(attribute.String("gapi.implementation", fmt.Sprintf("%T", implementation)))
+	}
+
+	c.SetUserContext(ctx)
+	err := step()
+	c.SetUserContext(parent)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
+	return err
+}
+
+// authorize checks that the caller may perform the operation on subject,
+// consulting the manager voters before the operation's own.
+func (r *Resource) authorize(c *fiber.Ctx, op Operation, operationConfig *OperationConfig, subject interface{}) error {
+	var voters []auth.Voter
+	if r.manager != nil {
+		voters = r.manager.voters
+	}
+	return auth.Authorize(c, r.security(op, operationConfig), voters, string(op), subject)
+}
+
+// denyAll refuses an operation to every caller the voters do not grant it.
+var denyAll = &auth.Security{Check: func(*fiber.Ctx, *auth.Principal, interface{}) bool { return false }}
+
+// security returns the Security of the operation. Restore defaults to the
+// Trash security, then to the delete one, and is denied when neither is
+// set: bringing records back is no less sensitive than removing them.
+func (r *Resource) security(op Operation, operationConfig *OperationConfig) *auth.Security {
+	if operationConfig.Security != nil || op != OperationRestore {
+		return operationConfig.Security
+	}
+	if r.config.Trash != nil {
+		return r.config.Trash
+	}
+	if deleteConfig, ok := r.config.Operations[OperationDelete]; ok && deleteConfig.Security != nil {
+		return deleteConfig.Security
+	}
+	return denyAll
+}
+
+// authorizeTrash checks that the caller may list the deleted records
+// requested with the SoftDelete query parameter.
+func (r *Resource) authorizeTrash(c *fiber.Ctx) error {
+	deleted, err := state.RequestedDeleted(c)
+	if err != nil || deleted == state.DeletedExclude {
+		return err
+	}
+	if r.config.Trash == nil {
+		return state.NewForbiddenError("deleted records are not available")
+	}
+	return auth.Authorize(c, r.config.Trash, nil, string(OperationGetList), nil)
+}
+
+// extensions lists the manager and resource query extensions in that order.
+func (r *Resource) extensions() []state.QueryExtension {
+	if r.manager == nil || len(r.manager.extensions) == 0 {
+		return r.config.Extensions
+	}
+	extensions := make([]state.QueryExtension, 0, len(r.manager.extensions)+len(r.config.Extensions))
+	extensions = append(extensions, r.manager.extensions...)
+	return append(extensions, r.config.Extensions...)
+}
+
+// hooks merges the manager, resource and operation hooks in that order.
+func (r *Resource) hooks(operationConfig *OperationConfig) state.Hooks {
+	var global state.Hooks
+	if r.manager != nil {
+		global = r.manager.hooks
+	}
+	return state.MergeHooks(global, r.config.Hooks, operationConfig.Hooks)
+}
+
+// newModel allocates a new zero value of the configured model type.
+// It accepts both pointer and non-pointer models and always returns a
+// pointer to a struct, or nil when the resource has no model.
+func (r *Resource) newModel() interface{} {
+	if r.config.Model == nil {
+		return nil
+	}
+
+	modelType := reflect.TypeOf(r.config.Model)
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	return reflect.New(modelType).Interface()
 }
 
 // Config returns the resource configuration.
@@ -7,9 +7,14 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/pkg/state"
+	"github.com/n3crone/gapi-platform/testutils"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestRegisterRoutes(t *testing.T) {
@@ -21,6 +26,7 @@ func TestRegisterRoutes(t *testing.T) {
 			OperationCreate:  true,
 			OperationGetItem: true,
 			OperationUpdate:  true,
+			OperationPatch:   true,
 			OperationDelete:  true,
 		})
 
@@ -46,6 +52,11 @@ func TestRegisterRoutes(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 
+		// Test PATCH /api/test/123 (Patch)
+		resp, err = app.Test(httptest.NewRequest(http.MethodPatch, "/api/test/123", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
 		// Test DELETE /api/test/123 (Delete)
 		resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/api/test/123", nil))
 		require.NoError(t, err)
@@ -59,6 +70,7 @@ func TestRegisterRoutes(t *testing.T) {
 			OperationCreate:  false,
 			OperationGetItem: false,
 			OperationUpdate:  false,
+			OperationPatch:   false,
 			OperationDelete:  false,
 		})
 
@@ -73,6 +85,7 @@ func TestRegisterRoutes(t *testing.T) {
 			{http.MethodPost, "/api/test"},
 			{http.MethodGet, "/api/test/123"},
 			{http.MethodPut, "/api/test/123"},
+			{http.MethodPatch, "/api/test/123"},
 			{http.MethodDelete, "/api/test/123"},
 		}
 
@@ -194,6 +207,15 @@ func TestRegisterRoutes(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("Rejects cursor pagination without a secret", func(t *testing.T) {
+		resource := createTestResource("/api/test", map[Operation]bool{OperationGetList: true})
+		resource.config.Pagination = state.PaginationConfig{Mode: state.PaginationModeCursor}
+
+		assert.PanicsWithValue(t, "resource /api/test: cursor pagination requires a CursorSecret", func() {
+			resource.RegisterRoutes(fiber.New())
+		})
+	})
 }
 
 // Enhanced mock implementations
@@ -234,6 +256,7 @@ func createTestResource(path string, operations map[Operation]bool) *Resource {
 		OperationCreate:  map[string]interface{}{"id": "2", "name": "created"},
 		OperationGetItem: map[string]interface{}{"id": "3", "name": "item"},
 		OperationUpdate:  map[string]interface{}{"id": "4", "name": "updated"},
+		OperationPatch:   map[string]interface{}{"id": "5", "name": "patched"},
 		OperationDelete:  nil, // Delete should return 204
 	}
 
@@ -251,3 +274,66 @@ func createTestResource(path string, operations map[Operation]bool) *Resource {
 		config: config,
 	}
 }
+
+// setupNotes serves model at /notes from a test database seeded with
+// the records. Callers are identified by the X-User header with the roles
+// of X-Roles.
+func setupNotes(t *testing.T, model interface{}, configure func(rm *ResourceManager, rc *ResourceConfig), records ...interface{}) (*fiber.App, *gorm.DB) {
+	db := testutils.NewTestDB(t, model)
+	for _, record := range records {
+		require.NoError(t, db.Model(model).Create(record).Error)
+	}
+
+	rm := NewResourceManager(db, nil)
+	resource := rm.CreateResource(model, func(rc *ResourceConfig) {
+		rc.Path = "/notes"
+		if configure != nil {
+			configure(rm, rc)
+		}
+	})
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if user := c.Get("X-User"); user != "" {
+			c.Locals("principal", &auth.Principal{ID: user, Roles: []string{c.Get("X-Roles")}})
+		}
+		return c.Next()
+	})
+	resource.RegisterRoutes(app)
+	return app, db
+}
+
+// as returns the headers identifying the caller to setupNotes resources.
+func as(user, role string) []string {
+	return []string{"X-User", user, "X-Roles", role}
+}
+
+func TestValidationGroups(t *testing.T) {
+	t.Run("Defaults to default group and operation name", func(t *testing.T) {
+		config := &OperationConfig{}
+		assert.Equal(t, []string{"default", "create"}, config.validationGroups(OperationCreate))
+		assert.Equal(t, []string{"default", "update"}, config.validationGroups(OperationUpdate))
+		assert.Equal(t, []string{"default", "update", "patch"}, config.validationGroups(OperationPatch))
+	})
+
+	t.Run("Uses configured groups", func(t *testing.T) {
+		config := &OperationConfig{ValidationGroups: []string{"admin"}}
+		assert.Equal(t, []string{"admin"}, config.validationGroups(OperationCreate))
+	})
+
+	t.Run("Checks update constraints on patch", func(t *testing.T) {
+		type reviewedNote struct {
+			ID     uint   `json:"id" gorm:"primarykey"`
+			Text   string `json:"text"`
+			Reason string `json:"reason" validate_update:"required"`
+		}
+		app, _ := setupNotes(t, &reviewedNote{}, nil, &reviewedNote{Text: "first"})
+
+		for _, method := range []string{"PUT", "PATCH"} {
+			status := testutils.Request(t, app, method, "/notes/1", `{"text":"edited"}`).Status
+			assert.Equal(t, fiber.StatusUnprocessableEntity, status, method)
+		}
+		status := testutils.Request(t, app, "PATCH", "/notes/1", `{"text":"edited","reason":"typo"}`).Status
+		assert.Equal(t, fiber.StatusOK, status)
+	})
+}
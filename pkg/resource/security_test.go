@@ -0,0 +1,84 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/pkg/state"
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type securedNote struct {
+	ID      uint   `json:"id" gorm:"primarykey"`
+	Text    string `json:"text"`
+	OwnerID string `json:"ownerId"`
+}
+
+// setupSecuredResource serves notes owned by "jane" and "john".
+func setupSecuredResource(t *testing.T, configure func(rm *ResourceManager, rc *ResourceConfig)) *fiber.App {
+	app, _ := setupNotes(t, &securedNote{}, configure, &securedNote{Text: "first", OwnerID: "jane"}, &securedNote{Text: "second", OwnerID: "john"})
+	return app
+}
+
+func ownsNote(_ *fiber.Ctx, principal *auth.Principal, subject interface{}) bool {
+	note, ok := subject.(*securedNote)
+	return ok && principal != nil && note.OwnerID == principal.ID
+}
+
+func TestSecurity(t *testing.T) {
+	t.Run("Authorizes collection operations before the provider", func(t *testing.T) {
+		var events []string
+		app := setupSecuredResource(t, func(_ *ResourceManager, rc *ResourceConfig) {
+			rc.Operations[OperationGetList].Security = &auth.Security{Roles: []string{"admin"}}
+			rc.Hooks.On(state.EventPreRead, recordEvents(&events, "pre_read"))
+		})
+
+		assert.Equal(t, fiber.StatusUnauthorized, testutils.Request(t, app, "GET", "/notes", "").Status)
+		assert.Equal(t, fiber.StatusForbidden, testutils.Request(t, app, "GET", "/notes", "", as("jane", "reader")...).Status)
+		assert.Empty(t, events, "nothing is loaded for denied callers")
+
+		assert.Equal(t, fiber.StatusOK, testutils.Request(t, app, "GET", "/notes", "", as("jane", "admin")...).Status)
+		assert.Equal(t, []string{"pre_read"}, events)
+	})
+
+	t.Run("Authorizes item operations on the loaded record", func(t *testing.T) {
+		app := setupSecuredResource(t, func(_ *ResourceManager, rc *ResourceConfig) {
+			rc.Operations[OperationGetItem].Security = &auth.Security{Check: ownsNote}
+			rc.Operations[OperationDelete].Security = &auth.Security{Check: ownsNote}
+		})
+
+		assert.Equal(t, fiber.StatusOK, testutils.Request(t, app, "GET", "/notes/1", "", as("jane", "")...).Status)
+		assert.Equal(t, fiber.StatusForbidden, testutils.Request(t, app, "GET", "/notes/2", "", as("jane", "")...).Status)
+		assert.Equal(t, fiber.StatusUnauthorized, testutils.Request(t, app, "GET", "/notes/1", "").Status)
+		assert.Equal(t, fiber.StatusNotFound, testutils.Request(t, app, "GET", "/notes/3", "", as("jane", "")...).Status)
+
+		assert.Equal(t, fiber.StatusForbidden, testutils.Request(t, app, "DELETE", "/notes/2", "", as("jane", "")...).Status)
+		assert.Equal(t, fiber.StatusOK, testutils.Request(t, app, "GET", "/notes/2", "", as("john", "")...).Status, "denied deletes are not processed")
+		assert.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/2", "", as("john", "")...).Status)
+	})
+
+	t.Run("Consults the manager voters", func(t *testing.T) {
+		app := setupSecuredResource(t, func(rm *ResourceManager, rc *ResourceConfig) {
+			// Owners may do anything with their notes, banned users nothing
+			rm.AddVoter(auth.VoterFunc(func(_ *fiber.Ctx, p *auth.Principal, _ string, subject interface{}) auth.Vote {
+				switch {
+				case p != nil && p.HasRole("banned"):
+					return auth.VoteDeny
+				case ownsNote(nil, p, subject):
+					return auth.VoteGrant
+				}
+				return auth.VoteAbstain
+			}))
+			rc.Operations[OperationDelete].Security = &auth.Security{Roles: []string{"admin"}}
+		})
+
+		assert.Equal(t, fiber.StatusForbidden, testutils.Request(t, app, "GET", "/notes", "", as("jane", "banned")...).Status, "voters run without Security")
+		assert.Equal(t, fiber.StatusOK, testutils.Request(t, app, "GET", "/notes", "", as("jane", "reader")...).Status)
+		assert.Equal(t, fiber.StatusForbidden, testutils.Request(t, app, "DELETE", "/notes/2", "", as("jane", "reader")...).Status)
+		assert.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/1", "", as("jane", "reader")...).Status)
+		assert.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/2", "", as("root", "admin")...).Status)
+	})
+}
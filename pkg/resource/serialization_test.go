@@ -0,0 +1,83 @@
+package resource
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type groupedUser struct {
+	ID       uint   `json:"id" gorm:"primarykey" groups:"list,item"`
+	Email    string `json:"email" groups:"list,item,create"`
+	Password string `json:"password" groups:"create"`
+	Bio      string `json:"bio" groups:"item,create,update"`
+	Admin    bool   `json:"admin" groups:"item"`
+}
+
+func setupGroupedResource(t *testing.T) (*fiber.App, func() groupedUser) {
+	user := &groupedUser{Email: "jane@example.com", Password: "hash", Bio: "hi", Admin: true}
+	app, db := setupNotes(t, &groupedUser{}, func(_ *ResourceManager, rc *ResourceConfig) {
+		rc.Path = "/users"
+		rc.Operations[OperationGetList].NormalizationGroups = []string{"list"}
+		rc.Operations[OperationGetItem].NormalizationGroups = []string{"item"}
+		rc.Operations[OperationCreate].NormalizationGroups = []string{"item"}
+		rc.Operations[OperationCreate].DenormalizationGroups = []string{"create"}
+		rc.Operations[OperationUpdate].NormalizationGroups = []string{"item"}
+		rc.Operations[OperationUpdate].DenormalizationGroups = []string{"update"}
+	}, user)
+
+	load := func() groupedUser {
+		var user groupedUser
+		require.NoError(t, db.First(&user, 1).Error)
+		return user
+	}
+	return app, load
+}
+
+func TestSerializationGroups(t *testing.T) {
+	t.Run("Exposes different fields per operation", func(t *testing.T) {
+		app, _ := setupGroupedResource(t)
+
+		list := testutils.Request(t, app, http.MethodGet, "/users", "")
+		require.Equal(t, fiber.StatusOK, list.Status)
+		var collection struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(list.Body), &collection))
+		require.Len(t, collection.Items, 1)
+		assert.JSONEq(t, `{"id":1,"email":"jane@example.com"}`, string(collection.Items[0]))
+		assert.Contains(t, list.Body, `"totalItems":1`)
+
+		item := testutils.Request(t, app, http.MethodGet, "/users/1", "")
+		require.Equal(t, fiber.StatusOK, item.Status)
+		assert.Equal(t, `{"id":1,"email":"jane@example.com","bio":"hi","admin":true}`, item.Body)
+	})
+
+	t.Run("Ignores fields that are not writable on create", func(t *testing.T) {
+		app, _ := setupGroupedResource(t)
+
+		created := testutils.Request(t, app, http.MethodPost, "/users",
+			`{"id":50,"email":"bob@example.com","password":"secret","bio":"yo","admin":true}`)
+		require.Equal(t, fiber.StatusOK, created.Status)
+		assert.Equal(t, `{"id":2,"email":"bob@example.com","bio":"yo","admin":false}`, created.Body)
+	})
+
+	t.Run("Keeps read-only fields on update", func(t *testing.T) {
+		app, load := setupGroupedResource(t)
+
+		updated := testutils.Request(t, app, http.MethodPut, "/users/1",
+			`{"email":"evil@example.com","password":"new","bio":"updated","admin":false}`)
+		require.Equal(t, fiber.StatusOK, updated.Status)
+		assert.Equal(t, `{"id":1,"email":"jane@example.com","bio":"updated","admin":true}`, updated.Body)
+
+		stored := load()
+		assert.Equal(t, "hash", stored.Password)
+		assert.Equal(t, "updated", stored.Bio)
+	})
+}
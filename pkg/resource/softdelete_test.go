@@ -0,0 +1,193 @@
+package resource
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type trashedNote struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	Text      string     `json:"text"`
+	DeletedAt *time.Time `json:"deletedAt"`
+}
+
+type archivedNote struct {
+	ID       uint           `json:"id" gorm:"primarykey"`
+	Text     string         `json:"text"`
+	Archived gorm.DeletedAt `json:"archived"`
+}
+
+// setupTrashResource serves two notes with soft deletion enabled, admins
+// being allowed to list deleted ones.
+func setupTrashResource(t *testing.T, model interface{}, configure func(rc *ResourceConfig)) (*fiber.App, *gorm.DB) {
+	return setupNotes(t, model, func(_ *ResourceManager, rc *ResourceConfig) {
+		rc.SoftDelete.Enabled = true
+		rc.Trash = &auth.Security{Roles: []string{"admin"}}
+		if configure != nil {
+			configure(rc)
+		}
+	}, map[string]interface{}{"text": "first"}, map[string]interface{}{"text": "second"})
+}
+
+// listIDs returns the identifiers of the notes listed to role.
+func listIDs(t *testing.T, app *fiber.App, target, role string) (int, []uint) {
+	var headers []string
+	if role != "" {
+		headers = as("root", role)
+	}
+	resp := testutils.Request(t, app, "GET", target, "", headers...)
+	if resp.Status != fiber.StatusOK {
+		return resp.Status, nil
+	}
+
+	var page struct {
+		Items []struct {
+			ID uint `json:"id"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &page))
+	ids := []uint{}
+	for _, item := range page.Items {
+		ids = append(ids, item.ID)
+	}
+	return resp.Status, ids
+}
+
+func TestSoftDelete(t *testing.T) {
+	for name, model := range map[string]interface{}{"Time pointer": &trashedNote{}, "GORM DeletedAt": &archivedNote{}} {
+		t.Run(name, func(t *testing.T) {
+			app, _ := setupTrashResource(t, model, func(rc *ResourceConfig) {
+				if _, ok := model.(*archivedNote); ok {
+					rc.SoftDelete.Column = "Archived"
+				}
+			})
+
+			assert.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/1", "").Status)
+			assert.Equal(t, fiber.StatusNotFound, testutils.Request(t, app, "GET", "/notes/1", "").Status)
+			assert.Equal(t, fiber.StatusNotFound, testutils.Request(t, app, "DELETE", "/notes/1", "").Status, "deleted records cannot be deleted again")
+
+			_, ids := listIDs(t, app, "/notes", "")
+			assert.Equal(t, []uint{2}, ids)
+			_, ids = listIDs(t, app, "/notes?deleted=include", "admin")
+			assert.Equal(t, []uint{1, 2}, ids)
+			_, ids = listIDs(t, app, "/notes?deleted=only", "admin")
+			assert.Equal(t, []uint{1}, ids)
+
+			assert.Equal(t, fiber.StatusOK, testutils.Request(t, app, "POST", "/notes/1/restore", "", as("root", "admin")...).Status)
+			assert.Equal(t, fiber.StatusOK, testutils.Request(t, app, "GET", "/notes/1", "").Status)
+			_, ids = listIDs(t, app, "/notes", "")
+			assert.Equal(t, []uint{1, 2}, ids)
+		})
+	}
+
+	t.Run("Marks the deletion time", func(t *testing.T) {
+		app, db := setupTrashResource(t, &trashedNote{}, nil)
+		before := time.Now()
+
+		require.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/2", "").Status)
+		var note trashedNote
+		require.NoError(t, db.First(&note, 2).Error)
+		require.NotNil(t, note.DeletedAt)
+		assert.False(t, note.DeletedAt.Before(before.Truncate(time.Second)))
+
+		resp := testutils.Request(t, app, "POST", "/notes/2/restore", "", as("root", "admin")...)
+		require.Equal(t, fiber.StatusOK, resp.Status)
+		var restored map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &restored))
+		assert.Nil(t, restored["deletedAt"])
+
+		var live trashedNote
+		require.NoError(t, db.First(&live, 2).Error)
+		assert.Nil(t, live.DeletedAt)
+	})
+
+	t.Run("Restricts deleted records to the trash security", func(t *testing.T) {
+		app, _ := setupTrashResource(t, &trashedNote{}, nil)
+
+		status, _ := listIDs(t, app, "/notes?deleted=only", "")
+		assert.Equal(t, fiber.StatusUnauthorized, status)
+		status, _ = listIDs(t, app, "/notes?deleted=only", "reader")
+		assert.Equal(t, fiber.StatusForbidden, status)
+		status, _ = listIDs(t, app, "/notes?deleted=exclude", "")
+		assert.Equal(t, fiber.StatusOK, status)
+		status, _ = listIDs(t, app, "/notes?deleted=all", "admin")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+
+		app, _ = setupTrashResource(t, &trashedNote{}, func(rc *ResourceConfig) { rc.Trash = nil })
+		status, _ = listIDs(t, app, "/notes?deleted=include", "admin")
+		assert.Equal(t, fiber.StatusForbidden, status)
+	})
+
+	t.Run("Secures restore like the trash or delete", func(t *testing.T) {
+		app, _ := setupTrashResource(t, &trashedNote{}, nil)
+		require.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/1", "").Status)
+
+		assert.Equal(t, fiber.StatusUnauthorized, testutils.Request(t, app, "POST", "/notes/1/restore", "").Status)
+		assert.Equal(t, fiber.StatusForbidden, testutils.Request(t, app, "POST", "/notes/1/restore", "", as("jane", "reader")...).Status)
+		assert.Equal(t, fiber.StatusNotFound, testutils.Request(t, app, "GET", "/notes/1", "").Status, "denied restores are not processed")
+
+		app, _ = setupTrashResource(t, &trashedNote{}, func(rc *ResourceConfig) {
+			rc.Trash = nil
+			rc.Operations[OperationDelete].Security = &auth.Security{Roles: []string{"editor"}}
+		})
+		require.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/1", "", as("jane", "editor")...).Status)
+		assert.Equal(t, fiber.StatusForbidden, testutils.Request(t, app, "POST", "/notes/1/restore", "", as("root", "admin")...).Status)
+		assert.Equal(t, fiber.StatusOK, testutils.Request(t, app, "POST", "/notes/1/restore", "", as("jane", "editor")...).Status)
+
+		app, _ = setupTrashResource(t, &trashedNote{}, func(rc *ResourceConfig) { rc.Trash = nil })
+		require.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/1", "").Status)
+		assert.Equal(t, fiber.StatusUnauthorized, testutils.Request(t, app, "POST", "/notes/1/restore", "").Status)
+		assert.Equal(t, fiber.StatusForbidden, testutils.Request(t, app, "POST", "/notes/1/restore", "", as("root", "admin")...).Status,
+			"nobody restores without security")
+	})
+
+	t.Run("Keeps the deletion time on writes", func(t *testing.T) {
+		app, db := setupTrashResource(t, &trashedNote{}, nil)
+		trashed := `{"text":"edited","deletedAt":"2024-01-01T00:00:00Z"}`
+
+		for _, method := range []string{"PUT", "PATCH"} {
+			require.Equal(t, fiber.StatusOK, testutils.Request(t, app, method, "/notes/1", trashed).Status, method)
+			assert.Equal(t, fiber.StatusOK, testutils.Request(t, app, "GET", "/notes/1", "").Status, method)
+		}
+		require.Equal(t, fiber.StatusOK, testutils.Request(t, app, "POST", "/notes", trashed).Status)
+
+		var notes []trashedNote
+		require.NoError(t, db.Find(&notes).Error)
+		require.Len(t, notes, 3)
+		for _, note := range notes {
+			assert.Nil(t, note.DeletedAt, note.ID)
+		}
+	})
+
+	t.Run("Uses the configured parameter", func(t *testing.T) {
+		app, _ := setupTrashResource(t, &trashedNote{}, func(rc *ResourceConfig) { rc.SoftDelete.Param = "trashed" })
+		require.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/1", "").Status)
+
+		_, ids := listIDs(t, app, "/notes?trashed=only", "admin")
+		assert.Equal(t, []uint{1}, ids)
+		_, ids = listIDs(t, app, "/notes?deleted=only", "admin")
+		assert.Equal(t, []uint{2}, ids)
+	})
+
+	t.Run("Hard deletes without soft deletion", func(t *testing.T) {
+		app, db := setupTrashResource(t, &trashedNote{}, func(rc *ResourceConfig) { rc.SoftDelete.Enabled = false })
+
+		require.Equal(t, fiber.StatusNoContent, testutils.Request(t, app, "DELETE", "/notes/1", "").Status)
+		var count int64
+		require.NoError(t, db.Model(&trashedNote{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, fiber.StatusNotFound, testutils.Request(t, app, "POST", "/notes/2/restore", "").Status, "restore is not routed")
+
+		_, ids := listIDs(t, app, "/notes?deleted=only", "")
+		assert.Equal(t, []uint{2}, ids, "the parameter is ignored")
+	})
+}
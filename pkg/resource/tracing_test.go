@@ -0,0 +1,79 @@
+package resource
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/tracing"
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type tracedBook struct {
+	ID    uint   `json:"id" gorm:"primarykey"`
+	Title string `json:"title"`
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracing.Install(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	db := testutils.NewTestDB(t, &tracedBook{})
+	require.NoError(t, db.Create(&tracedBook{Title: "Dune"}).Error)
+	require.NoError(t, db.Use(tracing.GormPlugin()))
+
+	app := fiber.New()
+	app.Use(tracing.Middleware())
+	NewResourceManager(db, nil).CreateResource(&tracedBook{}, func(rc *ResourceConfig) {
+		rc.Path = "/books"
+	}).RegisterRoutes(app)
+
+	t.Run("Traces each stage and its queries", func(t *testing.T) {
+		recorder.Reset()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/books/1", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		spans := make(map[string]sdktrace.ReadOnlySpan)
+		for _, span := range recorder.Ended() {
+			spans[span.Name()] = span
+		}
+		require.Contains(t, spans, "GET /books/:id")
+		request := spans["GET /books/:id"]
+
+		for _, name := range []string{"provide", "process", "serialize"} {
+			require.Contains(t, spans, name)
+			assert.Equal(t, request.SpanContext().SpanID(), spans[name].Parent().SpanID(), name)
+		}
+		require.Contains(t, spans, "gorm.query")
+		assert.Equal(t, spans["provide"].SpanContext().SpanID(), spans["gorm.query"].Parent().SpanID())
+	})
+
+	t.Run("Marks the failing stage", func(t *testing.T) {
+		recorder.Reset()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/books/99", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		var names []string
+		for _, span := range recorder.Ended() {
+			names = append(names, span.Name())
+			if span.Name() == "provide" {
+				assert.Equal(t, codes.Error, span.Status().Code)
+			}
+		}
+		assert.Contains(t, names, "provide")
+		assert.NotContains(t, names, "process")
+	})
+}
@@ -0,0 +1,142 @@
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/n3crone/gapi-platform/pkg/state"
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type versionedNote struct {
+	ID      uint   `json:"id" gorm:"primarykey"`
+	Text    string `json:"text"`
+	Version int    `json:"version"`
+}
+
+type stampedNote struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// setupVersionedResource serves notes versioned by the field, the first
+// one being created through the API.
+func setupVersionedResource(t *testing.T, model interface{}, config state.VersionConfig, configure func(rc *ResourceConfig)) (*fiber.App, *gorm.DB) {
+	app, db := setupNotes(t, model, func(_ *ResourceManager, rc *ResourceConfig) {
+		rc.Versioning = config
+		if configure != nil {
+			configure(rc)
+		}
+	})
+
+	status := testutils.Request(t, app, "POST", "/notes", `{"text":"first","version":42}`).Status
+	require.Equal(t, fiber.StatusOK, status)
+	return app, db
+}
+
+func TestVersioning(t *testing.T) {
+	t.Run("Versions records with an integer field", func(t *testing.T) {
+		app, db := setupVersionedResource(t, &versionedNote{}, state.VersionConfig{Field: "Version"}, nil)
+
+		resp := testutils.Request(t, app, "GET", "/notes/1", "")
+		require.Equal(t, fiber.StatusOK, resp.Status)
+		assert.Equal(t, `"1"`, resp.Header.Get(fiber.HeaderETag), "created records start at version 1")
+
+		status := testutils.Request(t, app, "PUT", "/notes/1", `{"text":"second","version":7}`, fiber.HeaderIfMatch, `"1"`).Status
+		require.Equal(t, fiber.StatusOK, status)
+		status = testutils.Request(t, app, "PATCH", "/notes/1", `{"text":"third"}`).Status
+		require.Equal(t, fiber.StatusOK, status, "If-Match is optional")
+
+		tag := testutils.Request(t, app, "GET", "/notes/1", "").Header.Get(fiber.HeaderETag)
+		assert.Equal(t, `"3"`, tag)
+
+		var note versionedNote
+		require.NoError(t, db.First(&note, 1).Error)
+		assert.Equal(t, versionedNote{ID: 1, Text: "third", Version: 3}, note)
+	})
+
+	t.Run("Rejects stale versions", func(t *testing.T) {
+		app, db := setupVersionedResource(t, &versionedNote{}, state.VersionConfig{Field: "Version"}, nil)
+		status := testutils.Request(t, app, "PATCH", "/notes/1", `{"text":"second"}`).Status
+		require.Equal(t, fiber.StatusOK, status)
+
+		for _, method := range []string{"PUT", "PATCH", "DELETE"} {
+			status := testutils.Request(t, app, method, "/notes/1", `{"text":"lost"}`, fiber.HeaderIfMatch, `"1"`).Status
+			assert.Equal(t, fiber.StatusPreconditionFailed, status, method)
+		}
+
+		var note versionedNote
+		require.NoError(t, db.First(&note, 1).Error)
+		assert.Equal(t, "second", note.Text)
+
+		status = testutils.Request(t, app, "PATCH", "/notes/1", `{"text":"third"}`, fiber.HeaderIfMatch, `"1", "2"`).Status
+		assert.Equal(t, fiber.StatusOK, status, "any listed ETag matches")
+		status = testutils.Request(t, app, "DELETE", "/notes/1", "", fiber.HeaderIfMatch, "*").Status
+		assert.Equal(t, fiber.StatusNoContent, status)
+	})
+
+	t.Run("Requires If-Match when configured", func(t *testing.T) {
+		app, _ := setupVersionedResource(t, &versionedNote{}, state.VersionConfig{Field: "Version", Required: true}, nil)
+
+		for _, method := range []string{"PUT", "PATCH", "DELETE"} {
+			status := testutils.Request(t, app, method, "/notes/1", `{"text":"blind"}`).Status
+			assert.Equal(t, fiber.StatusPreconditionRequired, status, method)
+		}
+		status := testutils.Request(t, app, "PUT", "/notes/1", `{"text":"second"}`, fiber.HeaderIfMatch, `"1"`).Status
+		assert.Equal(t, fiber.StatusOK, status)
+	})
+
+	t.Run("Checks the version atomically", func(t *testing.T) {
+		var db *gorm.DB
+		app, db := setupVersionedResource(t, &versionedNote{}, state.VersionConfig{Field: "Version"}, func(rc *ResourceConfig) {
+			// Another client writes between the load and the update
+			rc.Hooks.On(state.EventPreWrite, func(c *fiber.Ctx, data interface{}) (interface{}, error) {
+				if c.Method() != fiber.MethodPost {
+					require.NoError(t, db.Exec("UPDATE versioned_notes SET text = 'concurrent', version = version + 1").Error)
+				}
+				return data, nil
+			})
+		})
+
+		status := testutils.Request(t, app, "PUT", "/notes/1", `{"text":"lost"}`, fiber.HeaderIfMatch, `"1"`).Status
+		assert.Equal(t, fiber.StatusPreconditionFailed, status)
+		status = testutils.Request(t, app, "DELETE", "/notes/1", "", fiber.HeaderIfMatch, `"2"`).Status
+		assert.Equal(t, fiber.StatusPreconditionFailed, status)
+
+		var note versionedNote
+		require.NoError(t, db.First(&note, 1).Error)
+		assert.Equal(t, versionedNote{ID: 1, Text: "concurrent", Version: 3}, note)
+	})
+
+	t.Run("Versions records with their update time", func(t *testing.T) {
+		app, _ := setupVersionedResource(t, &stampedNote{}, state.VersionConfig{Field: "UpdatedAt"}, nil)
+
+		first := testutils.Request(t, app, "GET", "/notes/1", "").Header.Get(fiber.HeaderETag)
+		require.NotEmpty(t, first)
+
+		status := testutils.Request(t, app, "PUT", "/notes/1", `{"text":"second"}`, fiber.HeaderIfMatch, first).Status
+		require.Equal(t, fiber.StatusOK, status)
+		second := testutils.Request(t, app, "GET", "/notes/1", "").Header.Get(fiber.HeaderETag)
+		assert.NotEqual(t, first, second)
+
+		status = testutils.Request(t, app, "PUT", "/notes/1", `{"text":"lost"}`, fiber.HeaderIfMatch, first).Status
+		assert.Equal(t, fiber.StatusPreconditionFailed, status)
+		status = testutils.Request(t, app, "PUT", "/notes/1", `{"text":"third"}`, fiber.HeaderIfMatch, second).Status
+		assert.Equal(t, fiber.StatusOK, status)
+	})
+
+	t.Run("Sends no ETag without versioning", func(t *testing.T) {
+		app, _ := setupVersionedResource(t, &versionedNote{}, state.VersionConfig{}, nil)
+
+		tag := testutils.Request(t, app, "GET", "/notes/1", "").Header.Get(fiber.HeaderETag)
+		assert.Empty(t, tag)
+		status := testutils.Request(t, app, "PUT", "/notes/1", `{"text":"second"}`, fiber.HeaderIfMatch, `"0"`).Status
+		assert.Equal(t, fiber.StatusOK, status, "If-Match is ignored")
+	})
+}
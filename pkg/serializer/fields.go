@@ -0,0 +1,171 @@
+package serializer
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// field describes an exported struct field as seen by encoding/json,
+// together with the serialization groups it belongs to.
+type field struct {
+	name      string   // JSON property name
+	index     []int    // Index path, including promoted fields of embedded structs
+	omitEmpty bool     // Whether the json tag has the omitempty option
+	groups    []string // Groups from the `groups` struct tag
+}
+
+// typeInfo is the cached serialization metadata of a struct type.
+type typeInfo struct {
+	fields     []field
+	groupAware bool // At least one field declares groups
+}
+
+var typeCache sync.Map
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeFields returns the JSON fields of a struct type in declaration order.
+// Embedded structs without a JSON name are flattened like encoding/json does.
+func typeFields(t reflect.Type) *typeInfo {
+	if info, ok := typeCache.Load(t); ok {
+		return info.(*typeInfo)
+	}
+
+	info := &typeInfo{}
+	seen := make(map[string]bool)
+	collectFields(t, nil, info, seen)
+
+	actual, _ := typeCache.LoadOrStore(t, info)
+	return actual.(*typeInfo)
+}
+
+func collectFields(t reflect.Type, parent []int, info *typeInfo, seen map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		index := append(append([]int{}, parent...), i)
+
+		fieldType := sf.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if sf.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && !isMarshaler(fieldType) {
+			collectFields(fieldType, index, info, seen)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		// Shallower fields shadow promoted ones with the same name
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var groups []string
+		if raw, ok := sf.Tag.Lookup("groups"); ok {
+			info.groupAware = true
+			for _, group := range strings.Split(raw, ",") {
+				if group = strings.TrimSpace(group); group != "" {
+					groups = append(groups, group)
+				}
+			}
+		}
+
+		info.fields = append(info.fields, field{
+			name:      name,
+			index:     index,
+			omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+			groups:    groups,
+		})
+	}
+}
+
+// isMarshaler reports whether values of the type encode themselves, in which
+// case they are passed through untouched (time.Time, gorm.DeletedAt, ...).
+func isMarshaler(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// inGroups reports whether the field belongs to any of the given groups.
+func (f field) inGroups(groups []string) bool {
+	for _, group := range f.groups {
+		for _, wanted := range groups {
+			if group == wanted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fieldByIndex returns the field value, or an invalid value when an embedded
+// pointer on the path is nil.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// Field describes a JSON property of a struct type.
+type Field struct {
+	Name        string              // JSON property name
+	OmitEmpty   bool                // Whether the json tag has the omitempty option
+	StructField reflect.StructField // Go field, possibly promoted from an embedded struct
+}
+
+// Fields returns the JSON properties of a struct type that are read or
+// written with the given groups, in declaration order. It is meant for
+// generators that describe resources, such as API documentation.
+func Fields(t reflect.Type, groups []string) []Field {
+	info := typeFields(t)
+
+	fields := make([]Field, 0, len(info.fields))
+	for _, f := range info.fields {
+		if len(groups) > 0 && info.groupAware && !f.inGroups(groups) {
+			continue
+		}
+		fields = append(fields, Field{
+			Name:        f.name,
+			OmitEmpty:   f.omitEmpty,
+			StructField: t.FieldByIndex(f.index),
+		})
+	}
+	return fields
+}
+
+// JSONName returns the JSON property name of a model field, falling back to
+// its Go name when the field has no json tag like encoding/json does. Query
+// parameters naming fields, such as filters and sort orders, use it.
+func JSONName(field *schema.Field) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
@@ -0,0 +1,211 @@
+// Package serializer filters resource fields by serialization groups.
+//
+// Fields join groups through the `groups` struct tag:
+//
+//	type User struct {
+//		ID       uint   `json:"id" groups:"user:read"`
+//		Email    string `json:"email" groups:"user:read,user:write"`
+//		Password string `json:"password" groups:"user:write"`
+//	}
+//
+// A struct is group aware when at least one of its fields has a `groups`
+// tag. Only fields of the active groups are read or written on group aware
+// structs; other structs are handled as a whole, like encoding/json does.
+package serializer
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Normalize converts a value into a JSON-ready representation that only
+// contains the fields of the given groups. Nested structs, slices, maps and
+// envelopes such as paginated collections are walked recursively. Without
+// groups the value is returned unchanged.
+func Normalize(value interface{}, groups []string) interface{} {
+	if len(groups) == 0 || value == nil {
+		return value
+	}
+	return normalize(reflect.ValueOf(value), groups)
+}
+
+func normalize(v reflect.Value, groups []string) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Kind() != reflect.Interface && v.Kind() != reflect.Ptr && isMarshaler(v.Type()) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return normalize(v.Elem(), groups)
+
+	case reflect.Struct:
+		info := typeFields(v.Type())
+		object := make(orderedObject, 0, len(info.fields))
+		for _, f := range info.fields {
+			if info.groupAware && !f.inGroups(groups) {
+				continue
+			}
+			fv := fieldByIndex(v, f.index)
+			if !fv.IsValid() || (f.omitEmpty && isEmptyValue(fv)) {
+				continue
+			}
+			object = append(object, member{key: f.name, value: normalize(fv, groups)})
+		}
+		return object
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+
+	case reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = normalize(v.Index(i), groups)
+		}
+		return items
+
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		values := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = normalize(iter.Value(), groups)
+		}
+		return values
+
+	default:
+		return v.Interface()
+	}
+}
+
+// isEmptyValue matches the omitempty rules of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
+
+// Denormalize copies the fields of the given groups from src into dst.
+// Both must be pointers to the same struct type. Fields outside the groups
+// keep their current value in dst, which makes them read-only. Without groups
+// every field is copied.
+func Denormalize(dst, src interface{}, groups []string) {
+	dstValue := reflect.ValueOf(dst).Elem()
+	srcValue := reflect.ValueOf(src).Elem()
+
+	if len(groups) == 0 {
+		dstValue.Set(srcValue)
+		return
+	}
+	denormalize(dstValue, srcValue, groups)
+}
+
+func denormalize(dst, src reflect.Value, groups []string) {
+	info := typeFields(src.Type())
+	if !info.groupAware {
+		dst.Set(src)
+		return
+	}
+
+	for _, f := range info.fields {
+		if !f.inGroups(groups) {
+			continue
+		}
+		srcField := fieldByIndex(src, f.index)
+		dstField := fieldByIndexAlloc(dst, f.index)
+		if !srcField.IsValid() || !dstField.CanSet() {
+			continue
+		}
+
+		// Group aware nested structs only receive their own writable fields
+		if srcField.Kind() == reflect.Struct && !isMarshaler(srcField.Type()) && typeFields(srcField.Type()).groupAware {
+			denormalize(dstField, srcField, groups)
+			continue
+		}
+		dstField.Set(srcField)
+	}
+}
+
+// fieldByIndexAlloc returns the field value, allocating nil embedded pointers
+// on the path so that promoted fields can be set.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// member is a single property of an orderedObject.
+type member struct {
+	key   string
+	value interface{}
+}
+
+// orderedObject is a JSON object that keeps struct declaration order.
+type orderedObject []member
+
+// MarshalJSON encodes the members in order.
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Clear zeroes every field of the struct pointed to by v that is visible to
+// encoding/json. Fields hidden with `json:"-"` or unexported keep their value,
+// so that decoding a complete JSON representation into v afterwards yields
+// exactly the fields of the document plus the hidden state.
+func Clear(v interface{}) {
+	value := reflect.ValueOf(v).Elem()
+	for _, f := range typeFields(value.Type()).fields {
+		if fv := fieldByIndex(value, f.index); fv.IsValid() && fv.CanSet() {
+			fv.Set(reflect.Zero(fv.Type()))
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package serializer
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+)
+
+type Timestamps struct {
+	CreatedAt time.Time `json:"createdAt" groups:"user:item"`
+}
+
+type Profile struct {
+	Bio      string `json:"bio" groups:"user:read,user:write"`
+	Internal string `json:"internal"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type User struct {
+	Timestamps
+	ID       uint     `json:"id" groups:"user:read"`
+	Email    string   `json:"email" groups:"user:read,user:write"`
+	Password string   `json:"password" groups:"user:write"`
+	Admin    bool     `json:"admin"`
+	Nickname string   `json:"nickname,omitempty" groups:"user:read"`
+	Profile  *Profile `json:"profile" groups:"user:read,user:write"`
+	Tags     []Tag    `json:"tags" groups:"user:item"`
+}
+
+type envelope struct {
+	Items      interface{} `json:"items"`
+	TotalItems int64       `json:"totalItems"`
+}
+
+func marshal(t *testing.T, value interface{}) string {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func testUser() *User {
+	return &User{
+		Timestamps: Timestamps{CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		ID:         7,
+		Email:      "jane@example.com",
+		Password:   "hash",
+		Admin:      true,
+		Profile:    &Profile{Bio: "hello", Internal: "secret"},
+		Tags:       []Tag{{Name: "a"}},
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Run("Returns value unchanged without groups", func(t *testing.T) {
+		user := testUser()
+		assert.Same(t, user, Normalize(user, nil))
+	})
+
+	t.Run("Keeps only fields of the groups in declaration order", func(t *testing.T) {
+		result := marshal(t, Normalize(testUser(), []string{"user:read"}))
+		assert.Equal(t, `{"id":7,"email":"jane@example.com","profile":{"bio":"hello"}}`, result)
+	})
+
+	t.Run("Combines groups and promotes embedded fields", func(t *testing.T) {
+		result := marshal(t, Normalize(testUser(), []string{"user:read", "user:item"}))
+		assert.Equal(t, `{"createdAt":"2024-01-02T03:04:05Z","id":7,"email":"jane@example.com",`+
+			`"profile":{"bio":"hello"},"tags":[{"name":"a"}]}`, result)
+	})
+
+	t.Run("Walks envelopes and slices", func(t *testing.T) {
+		users := []User{*testUser(), {ID: 8, Nickname: "bob"}}
+		result := marshal(t, Normalize(&envelope{Items: &users, TotalItems: 2}, []string{"user:read"}))
+		assert.Equal(t, `{"items":[{"id":7,"email":"jane@example.com","profile":{"bio":"hello"}},`+
+			`{"id":8,"email":"","nickname":"bob","profile":null}],"totalItems":2}`, result)
+	})
+
+	t.Run("Normalizes maps", func(t *testing.T) {
+		result := marshal(t, Normalize(map[string]interface{}{"user": testUser()}, []string{"user:write"}))
+		assert.Equal(t, `{"user":{"email":"jane@example.com","password":"hash","profile":{"bio":"hello"}}}`, result)
+	})
+}
+
+func TestDenormalize(t *testing.T) {
+	t.Run("Copies only writable fields", func(t *testing.T) {
+		dst := testUser()
+		src := &User{ID: 99, Email: "new@example.com", Password: "new", Admin: false,
+			Profile: &Profile{Bio: "updated", Internal: "hacked"}}
+
+		Denormalize(dst, src, []string{"user:write"})
+
+		assert.Equal(t, uint(7), dst.ID)
+		assert.Equal(t, "new@example.com", dst.Email)
+		assert.Equal(t, "new", dst.Password)
+		assert.True(t, dst.Admin)
+		assert.Equal(t, &Profile{Bio: "updated", Internal: "hacked"}, dst.Profile,
+			"pointer fields are replaced as a whole")
+	})
+
+	t.Run("Filters nested group aware structs", func(t *testing.T) {
+		type Account struct {
+			Profile Profile `json:"profile" groups:"user:write"`
+		}
+		dst := &Account{Profile: Profile{Bio: "old", Internal: "keep"}}
+		src := &Account{Profile: Profile{Bio: "new", Internal: "hacked"}}
+
+		Denormalize(dst, src, []string{"user:write"})
+		assert.Equal(t, Profile{Bio: "new", Internal: "keep"}, dst.Profile)
+	})
+
+	t.Run("Copies everything without groups", func(t *testing.T) {
+		dst := testUser()
+		src := &User{ID: 99}
+
+		Denormalize(dst, src, nil)
+		assert.Equal(t, src, dst)
+	})
+}
+
+func TestClear(t *testing.T) {
+	type Account struct {
+		User
+		Token  string `json:"-"`
+		secret string
+	}
+	account := &Account{User: *testUser(), Token: "t", secret: "s"}
+
+	Clear(account)
+	assert.Equal(t, &Account{Token: "t", secret: "s"}, account)
+}
+
+func TestFields(t *testing.T) {
+	names := func(fields []Field) []string {
+		var result []string
+		for _, f := range fields {
+			result = append(result, f.Name)
+		}
+		return result
+	}
+	userType := reflect.TypeOf(User{})
+
+	assert.Equal(t, []string{"createdAt", "id", "email", "password", "admin", "nickname", "profile", "tags"},
+		names(Fields(userType, nil)))
+	assert.Equal(t, []string{"email", "password", "profile"}, names(Fields(userType, []string{"user:write"})))
+	assert.Equal(t, "CreatedAt", Fields(userType, nil)[0].StructField.Name)
+	assert.True(t, Fields(userType, nil)[5].OmitEmpty)
+}
+
+func TestJSONName(t *testing.T) {
+	type Article struct {
+		ID        uint   `json:"id,omitempty"`
+		Title     string `json:"-"`
+		Published bool
+	}
+	s, err := schema.Parse(&Article{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "id", JSONName(s.LookUpField("ID")))
+	assert.Equal(t, "Title", JSONName(s.LookUpField("Title")))
+	assert.Equal(t, "Published", JSONName(s.LookUpField("Published")))
+
+	// Untagged fields are named in responses as in query parameters
+	body, err := json.Marshal(Article{Published: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"`+JSONName(s.LookUpField("Published"))+`":true`)
+}
@@ -0,0 +1,239 @@
+package state
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// CursorCollection is the response envelope returned for cursor-paginated
+// collections. Cursors are opaque to clients and must be passed back
+// unchanged through the cursor query parameter.
+type CursorCollection struct {
+	Items        interface{} `json:"items"`                // Slice of records for the current page
+	ItemsPerPage int         `json:"itemsPerPage"`         // Page size used for this response
+	NextCursor   string      `json:"nextCursor,omitempty"` // Cursor of the next page, if any
+	PrevCursor   string      `json:"prevCursor,omitempty"` // Cursor of the previous page, if any
+	Next         string      `json:"next,omitempty"`       // Link to the next page, if any
+	Previous     string      `json:"previous,omitempty"`   // Link to the previous page, if any
+}
+
+// Cursor directions
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+// cursor identifies a position in a collection ordered by the cursor key.
+type cursor struct {
+	Value     json.RawMessage `json:"v"` // Key value of the boundary record
+	Direction string          `json:"d"` // Whether to page after or before Value
+}
+
+// encodeCursor serializes and signs a cursor as payload.signature using
+// URL-safe base64 for both parts.
+func encodeCursor(cur cursor, secret []byte) (string, error) {
+	payload, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// decodeCursor verifies the signature of an encoded cursor and decodes it.
+func decodeCursor(encoded string, secret []byte) (cursor, error) {
+	var cur cursor
+	invalid := NewBadRequestError("invalid cursor")
+
+	encodedPayload, encodedSignature, found := strings.Cut(encoded, ".")
+	if !found {
+		return cur, invalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return cur, invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return cur, invalid
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return cur, invalid
+	}
+
+	if err := json.Unmarshal(payload, &cur); err != nil {
+		return cur, invalid
+	}
+	if cur.Direction != cursorNext && cur.Direction != cursorPrev {
+		return cur, invalid
+	}
+
+	return cur, nil
+}
+
+// cursorField resolves the schema field used as cursor key: the configured
+// column or field name, or the primary key when none is configured.
+func (p *DefaultProvider) cursorField(modelType interface{}, config PaginationConfig) (*schema.Field, error) {
+	modelSchema, err := parseSchema(p.DB, modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	var field *schema.Field
+	if config.CursorKey != "" {
+		field = modelSchema.LookUpField(config.CursorKey)
+	} else {
+		field = modelSchema.PrioritizedPrimaryField
+	}
+	if field == nil {
+		return nil, NewInternalError("invalid cursor key", nil)
+	}
+
+	return field, nil
+}
+
+// findAllByCursor retrieves one page of records using keyset pagination.
+// One extra record is fetched to detect whether more records follow in the
+// paging direction, so no COUNT query is needed.
+func (p *DefaultProvider) findAllByCursor(c *fiber.Ctx, modelType interface{}, config PaginationConfig, filter func(*gorm.DB) *gorm.DB) (interface{}, error) {
+	sizeParam := "itemsPerPage"
+	if c.Query("limit") != "" {
+		sizeParam = "limit"
+	}
+
+	limit, err := parsePageSize(c, config, sizeParam)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keyset pagination always follows the cursor key
+	if hasOrderParams(c) {
+		return nil, NewBadRequestError("ordering is not supported with cursor pagination")
+	}
+
+	field, err := p.cursorField(modelType, config)
+	if err != nil {
+		return nil, err
+	}
+	secret := config.CursorSecret
+	if len(secret) == 0 {
+		return nil, NewInternalError("cursor pagination requires a CursorSecret", nil)
+	}
+
+	cur := cursor{Direction: cursorNext}
+	var boundary interface{}
+	if raw := c.Query("cursor"); raw != "" {
+		if cur, err = decodeCursor(raw, secret); err != nil {
+			return nil, err
+		}
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(cur.Value, value.Interface()); err != nil {
+			return nil, NewBadRequestError("invalid cursor")
+		}
+		boundary = value.Elem().Interface()
+	}
+
+	column := fieldColumn(field)
+	backward := cur.Direction == cursorPrev
+	scope := func(db *gorm.DB) *gorm.DB {
+		if boundary != nil {
+			if backward {
+				db = db.Where(clause.Lt{Column: column, Value: boundary})
+			} else {
+				db = db.Where(clause.Gt{Column: column, Value: boundary})
+			}
+		}
+		return db.Order(clause.OrderByColumn{Column: column, Desc: backward}).Limit(limit + 1)
+	}
+
+	results := newSlice(modelType)
+	if err := p.conn(c).Scopes(filter, scope).Find(results).Error; err != nil {
+		return nil, NewInternalError("failed to fetch records", err)
+	}
+
+	items := reflect.ValueOf(results).Elem()
+	hasMore := items.Len() > limit
+	if hasMore {
+		items.Set(items.Slice(0, limit))
+	}
+	if backward {
+		reverseSlice(items)
+	}
+
+	collection := &CursorCollection{Items: results, ItemsPerPage: limit}
+	if items.Len() == 0 {
+		return collection, nil
+	}
+
+	// Going forward there is a next page if we over-fetched and a previous
+	// page if we started from a cursor; going backward the reverse applies.
+	hasNext, hasPrev := hasMore, boundary != nil
+	if backward {
+		hasNext, hasPrev = boundary != nil, hasMore
+	}
+
+	if hasNext {
+		last := items.Index(items.Len() - 1)
+		if collection.NextCursor, err = fieldCursor(c, field, last, cursorNext, secret); err != nil {
+			return nil, err
+		}
+		collection.Next = cursorLink(c, collection.NextCursor)
+	}
+	if hasPrev {
+		if collection.PrevCursor, err = fieldCursor(c, field, items.Index(0), cursorPrev, secret); err != nil {
+			return nil, err
+		}
+		collection.Previous = cursorLink(c, collection.PrevCursor)
+	}
+
+	return collection, nil
+}
+
+// fieldCursor builds a signed cursor from the key value of a record.
+func fieldCursor(c *fiber.Ctx, field *schema.Field, record reflect.Value, direction string, secret []byte) (string, error) {
+	value, _ := field.ValueOf(c.UserContext(), record)
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", NewInternalError("failed to encode cursor", err)
+	}
+
+	encoded, err := encodeCursor(cursor{Value: raw, Direction: direction}, secret)
+	if err != nil {
+		return "", NewInternalError("failed to encode cursor", err)
+	}
+	return encoded, nil
+}
+
+// cursorLink builds a link to the page identified by the given cursor,
+// preserving the remaining query parameters.
+func cursorLink(c *fiber.Ctx, encoded string) string {
+	query := currentQuery(c)
+	query.Set("cursor", encoded)
+	return c.Path() + "?" + query.Encode()
+}
+
+// reverseSlice reverses the elements of a slice value in place.
+func reverseSlice(v reflect.Value) {
+	swap := reflect.Swapper(v.Interface())
+	for i, j := 0, v.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cursorPage struct {
+	Items        []TestModel `json:"items"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	NextCursor   string      `json:"nextCursor"`
+	PrevCursor   string      `json:"prevCursor"`
+	Next         string      `json:"next"`
+	Previous     string      `json:"previous"`
+}
+
+// setupCursorApp registers a cursor-paginated list route over count records.
+func setupCursorApp(t *testing.T, count int, config PaginationConfig) *fiber.App {
+	provider, app := setupTestListProvider(t, count)
+	config.Mode = PaginationModeCursor
+
+	app.Get("/items", func(c *fiber.Ctx) error {
+		c.Locals("model", &TestModel{})
+		c.Locals("pagination", config)
+		data, err := provider.Provide(c)
+		if err != nil {
+			return err
+		}
+		return c.JSON(data)
+	})
+
+	return app
+}
+
+func fetchCursorPage(t *testing.T, app *fiber.App, target string) (int, cursorPage) {
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	require.NoError(t, err)
+
+	var page cursorPage
+	if resp.StatusCode == fiber.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &page))
+	}
+	return resp.StatusCode, page
+}
+
+func itemIDs(items []TestModel) []uint {
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestCursorPagination(t *testing.T) {
+	secret := []byte("test-secret")
+
+	t.Run("Walks forward and backward through the collection", func(t *testing.T) {
+		app := setupCursorApp(t, 7, PaginationConfig{DefaultPageSize: 3, CursorSecret: secret})
+
+		status, first := fetchCursorPage(t, app, "/items")
+		require.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, []uint{1, 2, 3}, itemIDs(first.Items))
+		assert.Empty(t, first.PrevCursor)
+		require.NotEmpty(t, first.NextCursor)
+		assert.Equal(t, "/items?cursor="+url.QueryEscape(first.NextCursor), first.Next)
+
+		_, second := fetchCursorPage(t, app, first.Next)
+		assert.Equal(t, []uint{4, 5, 6}, itemIDs(second.Items))
+		require.NotEmpty(t, second.NextCursor)
+		require.NotEmpty(t, second.PrevCursor)
+
+		_, third := fetchCursorPage(t, app, second.Next)
+		assert.Equal(t, []uint{7}, itemIDs(third.Items))
+		assert.Empty(t, third.NextCursor)
+		require.NotEmpty(t, third.PrevCursor)
+
+		_, back := fetchCursorPage(t, app, third.Previous)
+		assert.Equal(t, []uint{4, 5, 6}, itemIDs(back.Items))
+		assert.NotEmpty(t, back.NextCursor)
+		assert.NotEmpty(t, back.PrevCursor)
+
+		_, start := fetchCursorPage(t, app, back.Previous)
+		assert.Equal(t, []uint{1, 2, 3}, itemIDs(start.Items))
+		assert.Empty(t, start.PrevCursor)
+		assert.NotEmpty(t, start.NextCursor)
+	})
+
+	t.Run("Custom cursor key", func(t *testing.T) {
+		app := setupCursorApp(t, 4, PaginationConfig{DefaultPageSize: 2, CursorKey: "name", CursorSecret: secret})
+
+		_, first := fetchCursorPage(t, app, "/items?itemsPerPage=3")
+		assert.Equal(t, []uint{1, 2, 3}, itemIDs(first.Items))
+
+		_, second := fetchCursorPage(t, app, first.Next)
+		assert.Equal(t, []uint{4}, itemIDs(second.Items))
+	})
+
+	t.Run("Rejects tampered or foreign cursors", func(t *testing.T) {
+		app := setupCursorApp(t, 5, PaginationConfig{DefaultPageSize: 2, CursorSecret: secret})
+		_, first := fetchCursorPage(t, app, "/items")
+
+		foreign, err := encodeCursor(cursor{Value: json.RawMessage("2"), Direction: cursorNext}, []byte("other"))
+		require.NoError(t, err)
+
+		for _, raw := range []string{"garbage", first.NextCursor + "x", foreign} {
+			status, _ := fetchCursorPage(t, app, "/items?cursor="+url.QueryEscape(raw))
+			assert.Equal(t, fiber.StatusBadRequest, status, raw)
+		}
+	})
+
+	t.Run("Requires a secret", func(t *testing.T) {
+		assert.Error(t, PaginationConfig{Mode: PaginationModeCursor}.Validate())
+		assert.NoError(t, PaginationConfig{Mode: PaginationModeCursor, CursorSecret: secret}.Validate())
+		assert.NoError(t, PaginationConfig{Mode: PaginationModeCursor, Disabled: true}.Validate())
+
+		app := setupCursorApp(t, 3, PaginationConfig{DefaultPageSize: 2})
+		status, _ := fetchCursorPage(t, app, "/items")
+		assert.Equal(t, fiber.StatusInternalServerError, status)
+	})
+}
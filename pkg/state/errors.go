@@ -0,0 +1,208 @@
+package state
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Problem is an RFC 7807 problem details object describing an error response.
+// Extensions are serialized as additional top-level members.
+type Problem struct {
+	Type       string                 `json:"type"`               // URI identifying the problem type
+	Title      string                 `json:"title"`              // Short summary of the problem type
+	Status     int                    `json:"status"`             // HTTP status code
+	Detail     string                 `json:"detail,omitempty"`   // Explanation specific to this occurrence
+	Instance   string                 `json:"instance,omitempty"` // URI of the request that caused the problem
+	Extensions map[string]interface{} `json:"-"`                  // Additional members such as trace IDs
+}
+
+// MarshalJSON flattens extension members into the problem object. Standard
+// members always take precedence over extensions with the same name.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+
+	return json.Marshal(members)
+}
+
+// HTTPError is the base of the typed error hierarchy returned by providers
+// and processors. The application error handler renders it as a Problem.
+type HTTPError struct {
+	Status     int                    // HTTP status code
+	Type       string                 // Problem type URI, defaults to about:blank
+	Title      string                 // Problem title, defaults to the status text
+	Detail     string                 // Human readable explanation
+	Extensions map[string]interface{} // Additional problem members
+	Err        error                  // Underlying cause, never exposed to clients
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	return http.StatusText(e.Status)
+}
+
+// Unwrap returns the underlying cause.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// As lets errors.As extract the base *HTTPError from any error of the
+// hierarchy, and convert it into *fiber.Error so that typed errors keep
+// their status code with Fiber's default error handler.
+func (e *HTTPError) As(target interface{}) bool {
+	switch t := target.(type) {
+	case **HTTPError:
+		*t = e
+		return true
+	case **fiber.Error:
+		*t = fiber.NewError(e.Status, e.Error())
+		return true
+	}
+	return false
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *HTTPError) StatusCode() int {
+	return e.Status
+}
+
+// Problem converts the error into problem details.
+func (e *HTTPError) Problem() *Problem {
+	problem := &Problem{
+		Type:   e.Type,
+		Title:  e.Title,
+		Status: e.Status,
+		Detail: e.Detail,
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(e.Status)
+	}
+	if len(e.Extensions) > 0 {
+		problem.Extensions = make(map[string]interface{}, len(e.Extensions))
+		for key, value := range e.Extensions {
+			problem.Extensions[key] = value
+		}
+	}
+	return problem
+}
+
+// ProblemError is implemented by errors that can describe themselves as
+// problem details, including every type of the HTTPError hierarchy.
+type ProblemError interface {
+	error
+	Problem() *Problem
+}
+
+// BadRequestError reports a malformed request (400).
+type BadRequestError struct{ HTTPError }
+
+// UnauthorizedError reports missing or invalid credentials (401).
+type UnauthorizedError struct{ HTTPError }
+
+// ForbiddenError reports that the caller may not perform the operation (403).
+type ForbiddenError struct{ HTTPError }
+
+// NotFoundError reports that the requested resource does not exist (404).
+type NotFoundError struct{ HTTPError }
+
+// ConflictError reports a conflict with the current state of the resource (409).
+type ConflictError struct{ HTTPError }
+
+// PreconditionFailedError reports that the If-Match precondition of the
+// request does not hold for the current state of the resource (412).
+type PreconditionFailedError struct{ HTTPError }
+
+// PreconditionRequiredError reports that the request must be conditional (428).
+type PreconditionRequiredError struct{ HTTPError }
+
+// ValidationError reports a well-formed request with invalid content (422).
+// Violations are rendered as the "violations" problem member.
+type ValidationError struct {
+	HTTPError
+	Violations []Violation
+}
+
+// Problem converts the error into problem details including its violations.
+func (e *ValidationError) Problem() *Problem {
+	problem := e.HTTPError.Problem()
+	if len(e.Violations) > 0 {
+		if problem.Extensions == nil {
+			problem.Extensions = make(map[string]interface{}, 1)
+		}
+		problem.Extensions["violations"] = e.Violations
+	}
+	return problem
+}
+
+// InternalError reports an unexpected server-side failure (500).
+type InternalError struct{ HTTPError }
+
+// NewBadRequestError creates a 400 Bad Request error.
+func NewBadRequestError(detail string) *BadRequestError {
+	return &BadRequestError{HTTPError{Status: fiber.StatusBadRequest, Detail: detail}}
+}
+
+// NewUnauthorizedError creates a 401 Unauthorized error.
+func NewUnauthorizedError(detail string) *UnauthorizedError {
+	return &UnauthorizedError{HTTPError{Status: fiber.StatusUnauthorized, Detail: detail}}
+}
+
+// NewForbiddenError creates a 403 Forbidden error.
+func NewForbiddenError(detail string) *ForbiddenError {
+	return &ForbiddenError{HTTPError{Status: fiber.StatusForbidden, Detail: detail}}
+}
+
+// NewNotFoundError creates a 404 Not Found error.
+func NewNotFoundError(detail string) *NotFoundError {
+	return &NotFoundError{HTTPError{Status: fiber.StatusNotFound, Detail: detail}}
+}
+
+// NewConflictError creates a 409 Conflict error wrapping the given cause.
+func NewConflictError(detail string, cause error) *ConflictError {
+	return &ConflictError{HTTPError{Status: fiber.StatusConflict, Detail: detail, Err: cause}}
+}
+
+// NewPreconditionFailedError creates a 412 Precondition Failed error.
+func NewPreconditionFailedError(detail string) *PreconditionFailedError {
+	return &PreconditionFailedError{HTTPError{Status: fiber.StatusPreconditionFailed, Detail: detail}}
+}
+
+// NewPreconditionRequiredError creates a 428 Precondition Required error.
+func NewPreconditionRequiredError(detail string) *PreconditionRequiredError {
+	return &PreconditionRequiredError{HTTPError{Status: fiber.StatusPreconditionRequired, Detail: detail}}
+}
+
+// NewValidationError creates a 422 Unprocessable Entity error with the
+// given constraint violations.
+func NewValidationError(detail string, violations ...Violation) *ValidationError {
+	return &ValidationError{
+		HTTPError:  HTTPError{Status: fiber.StatusUnprocessableEntity, Detail: detail},
+		Violations: violations,
+	}
+}
+
+// NewInternalError creates a 500 Internal Server Error wrapping the given cause.
+// The cause is kept for logging and never rendered to clients.
+func NewInternalError(detail string, cause error) *InternalError {
+	return &InternalError{HTTPError{Status: fiber.StatusInternalServerError, Detail: detail, Err: cause}}
+}
@@ -0,0 +1,106 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHierarchy(t *testing.T) {
+	t.Run("Constructors set status codes", func(t *testing.T) {
+		tests := []struct {
+			err    ProblemError
+			status int
+		}{
+			{NewBadRequestError("x"), fiber.StatusBadRequest},
+			{NewUnauthorizedError("x"), fiber.StatusUnauthorized},
+			{NewForbiddenError("x"), fiber.StatusForbidden},
+			{NewNotFoundError("x"), fiber.StatusNotFound},
+			{NewConflictError("x", nil), fiber.StatusConflict},
+			{NewPreconditionFailedError("x"), fiber.StatusPreconditionFailed},
+			{NewPreconditionRequiredError("x"), fiber.StatusPreconditionRequired},
+			{NewValidationError("x"), fiber.StatusUnprocessableEntity},
+			{NewInternalError("x", nil), fiber.StatusInternalServerError},
+		}
+
+		for _, tt := range tests {
+			problem := tt.err.Problem()
+			assert.Equal(t, tt.status, problem.Status)
+			assert.Equal(t, "about:blank", problem.Type)
+			assert.NotEmpty(t, problem.Title)
+			assert.Equal(t, "x", problem.Detail)
+		}
+	})
+
+	t.Run("Supports errors.As for base, specific and Fiber errors", func(t *testing.T) {
+		cause := errors.New("connection reset")
+		var err error = NewInternalError("database error", cause)
+
+		var base *HTTPError
+		require.ErrorAs(t, err, &base)
+		assert.Equal(t, fiber.StatusInternalServerError, base.StatusCode())
+
+		var internal *InternalError
+		require.ErrorAs(t, err, &internal)
+
+		var fiberErr *fiber.Error
+		require.ErrorAs(t, err, &fiberErr)
+		assert.Equal(t, fiber.StatusInternalServerError, fiberErr.Code)
+		assert.Equal(t, "database error", fiberErr.Message)
+
+		assert.ErrorIs(t, err, cause)
+
+		var notFound *NotFoundError
+		assert.False(t, errors.As(err, &notFound))
+	})
+
+	t.Run("Problem flattens extensions", func(t *testing.T) {
+		err := NewValidationError("invalid")
+		err.Extensions = map[string]interface{}{"violations": []string{"name"}, "title": "ignored"}
+
+		data, marshalErr := json.Marshal(err.Problem())
+		require.NoError(t, marshalErr)
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Unprocessable Entity",
+			"status": 422,
+			"detail": "invalid",
+			"violations": ["name"]
+		}`, string(data))
+	})
+}
+
+type UniqueModel struct {
+	ID    uint   `json:"id" gorm:"primarykey"`
+	Email string `json:"email" gorm:"uniqueIndex"`
+}
+
+func TestProcessConflict(t *testing.T) {
+	db := testutils.NewTestDB(t, &UniqueModel{})
+	require.NoError(t, db.Create(&UniqueModel{Email: "taken@example.com"}).Error)
+
+	processor := &DefaultProcessor{DB: db}
+	app := fiber.New()
+	app.Post("/users", func(c *fiber.Ctx) error {
+		c.Locals("model", &UniqueModel{})
+		_, err := processor.Process(c, nil)
+
+		var conflict *ConflictError
+		assert.ErrorAs(t, err, &conflict)
+		return err
+	})
+
+	req := httptest.NewRequest("POST", "/users", bytes.NewBufferString(`{"email":"taken@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Event names a step of the operation pipeline where hooks run, after API
+// Platform's kernel events.
+type Event string
+
+const (
+	// EventPreRead runs before the provider with nil data. Returning data
+	// skips the provider, e.g. to serve a cached record.
+	EventPreRead Event = "pre_read"
+	// EventPostRead runs after the provider with the record or collection.
+	EventPostRead Event = "post_read"
+	// EventPreValidate runs on create, update and patch with the instance
+	// built from the request, before it is validated.
+	EventPreValidate Event = "pre_validate"
+	// EventPreWrite runs with the validated instance, or the record about to
+	// be deleted, before it is written.
+	EventPreWrite Event = "pre_write"
+	// EventPostWrite runs with the stored or deleted record after the write.
+	// Delete responses stay empty whatever the hooks return.
+	EventPostWrite Event = "post_write"
+	// EventPreSerialize runs with the result before it is normalized into
+	// the response; nil data answers 204 No Content.
+	EventPreSerialize Event = "pre_serialize"
+)
+
+// Hook reacts to an event. It returns the data to continue with, either the
+// data it received or a replacement, or an error which aborts the request
+// and is rendered like provider and processor errors. Errors after a write
+// do not undo it.
+type Hook func(c *fiber.Ctx, data interface{}) (interface{}, error)
+
+// Hooks lists the hooks of each event in the order they run.
+type Hooks map[Event][]Hook
+
+// On appends hooks to an event.
+//
+// Example usage:
+//
+//	rc.Hooks.On(state.EventPostWrite, func(c *fiber.Ctx, data interface{}) (interface{}, error) {
+//		if c.Locals("operation") == "create" {
+//			mailer.Welcome(data.(*User))
+//		}
+//		return data, nil
+//	})
+func (h *Hooks) On(event Event, hooks ...Hook) {
+	if *h == nil {
+		*h = make(Hooks)
+	}
+	(*h)[event] = append((*h)[event], hooks...)
+}
+
+// MergeHooks combines hook sets, running the hooks of earlier sets first.
+func MergeHooks(sets ...Hooks) Hooks {
+	merged := make(Hooks)
+	for _, set := range sets {
+		for event, hooks := range set {
+			merged[event] = append(merged[event], hooks...)
+		}
+	}
+	return merged
+}
+
+// Dispatch runs the hooks of the event set in context by the resource,
+// passing each one the data returned by the previous one, and returns the
+// final data. The first error stops the chain. Custom providers and
+// processors call it to trigger the events they take over, e.g. pre_write.
+func Dispatch(c *fiber.Ctx, event Event, data interface{}) (interface{}, error) {
+	hooks, _ := c.Locals("hooks").(Hooks)
+	for _, hook := range hooks[event] {
+		var err error
+		if data, err = hook(c, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,98 @@
+package state
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendHook(suffix string) Hook {
+	return func(_ *fiber.Ctx, data interface{}) (interface{}, error) {
+		return data.(string) + suffix, nil
+	}
+}
+
+func TestHooks(t *testing.T) {
+	t.Run("On initializes the map", func(t *testing.T) {
+		var hooks Hooks
+		hooks.On(EventPreWrite, appendHook("a"), appendHook("b"))
+		hooks.On(EventPreWrite, appendHook("c"))
+
+		assert.Len(t, hooks[EventPreWrite], 3)
+	})
+
+	t.Run("MergeHooks keeps the set order", func(t *testing.T) {
+		var first, second Hooks
+		first.On(EventPostRead, appendHook("1"))
+		second.On(EventPostRead, appendHook("2"))
+		second.On(EventPreRead, appendHook("3"))
+
+		merged := MergeHooks(first, nil, second)
+		require.Len(t, merged[EventPostRead], 2)
+		data, err := merged[EventPostRead][1](nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, "2", data)
+		assert.Len(t, merged[EventPreRead], 1)
+		assert.Len(t, first[EventPostRead], 1, "sets are not modified")
+	})
+}
+
+func TestDispatch(t *testing.T) {
+	dispatch := func(t *testing.T, hooks interface{}, event Event, data interface{}) (interface{}, error) {
+		var (
+			result interface{}
+			err    error
+		)
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			if hooks != nil {
+				c.Locals("hooks", hooks)
+			}
+			result, err = Dispatch(c, event, data)
+			return nil
+		})
+		_, testErr := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, testErr)
+		return result, err
+	}
+
+	t.Run("Chains the data through the hooks", func(t *testing.T) {
+		var hooks Hooks
+		hooks.On(EventPreSerialize, appendHook("b"), appendHook("c"))
+
+		data, err := dispatch(t, hooks, EventPreSerialize, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", data)
+	})
+
+	t.Run("Stops at the first error", func(t *testing.T) {
+		failure := errors.New("rejected")
+		called := false
+		var hooks Hooks
+		hooks.On(EventPreWrite,
+			func(*fiber.Ctx, interface{}) (interface{}, error) { return nil, failure },
+			func(_ *fiber.Ctx, data interface{}) (interface{}, error) { called = true; return data, nil },
+		)
+
+		data, err := dispatch(t, hooks, EventPreWrite, "a")
+		assert.ErrorIs(t, err, failure)
+		assert.Nil(t, data)
+		assert.False(t, called)
+	})
+
+	t.Run("Passes data through without hooks", func(t *testing.T) {
+		data, err := dispatch(t, nil, EventPostRead, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "a", data)
+
+		var hooks Hooks
+		hooks.On(EventPreRead, appendHook("b"))
+		data, err = dispatch(t, hooks, EventPostRead, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "a", data, "hooks of other events do not run")
+	})
+}
@@ -0,0 +1,107 @@
+package state
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// QueryExtension restricts the rows the default provider reads, e.g. to the
+// tenant of the caller. Its scope applies to every query of the provider:
+// item lookups, collection pages, counts and cursor pages, so that rows
+// outside the scope answer 404 and never appear in collections.
+type QueryExtension interface {
+	// Scope returns the conditions of the request's queries on the model,
+	// or nil to leave them unchanged, e.g. for models the extension does
+	// not handle. Errors abort the request.
+	Scope(c *fiber.Ctx, model *schema.Schema) (func(*gorm.DB) *gorm.DB, error)
+}
+
+// WriteExtension is implemented by query extensions that enforce values on
+// the records written by the default processor, so that clients cannot
+// create or move records outside the scope.
+type WriteExtension interface {
+	// Enforce sets the guaranteed values on a record about to be created,
+	// updated or patched, before it is validated.
+	Enforce(c *fiber.Ctx, model *schema.Schema, instance interface{}) error
+}
+
+// QueryExtensionFunc adapts a function to the QueryExtension interface.
+type QueryExtensionFunc func(c *fiber.Ctx, model *schema.Schema) (func(*gorm.DB) *gorm.DB, error)
+
+// Scope calls f(c, model).
+func (f QueryExtensionFunc) Scope(c *fiber.Ctx, model *schema.Schema) (func(*gorm.DB) *gorm.DB, error) {
+	return f(c, model)
+}
+
+// queryExtensions returns the extensions set in context by the resource.
+func queryExtensions(c *fiber.Ctx) []QueryExtension {
+	extensions, _ := c.Locals("queryExtensions").([]QueryExtension)
+	return extensions
+}
+
+// extensionScope combines the scopes of the query extensions for the model,
+// or returns nil when none applies.
+func (p *DefaultProvider) extensionScope(c *fiber.Ctx, modelType interface{}) (func(*gorm.DB) *gorm.DB, error) {
+	extensions := queryExtensions(c)
+	if len(extensions) == 0 {
+		return nil, nil
+	}
+
+	modelSchema, err := parseSchema(p.DB, modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	var scopes []func(*gorm.DB) *gorm.DB
+	for _, extension := range extensions {
+		scope, err := extension.Scope(c, modelSchema)
+		if err != nil {
+			return nil, err
+		}
+		if scope != nil {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+
+	return chainScopes(scopes...), nil
+}
+
+// chainScopes combines scopes into one. They are applied directly since
+// GORM does not run the scopes registered while it runs scopes.
+func chainScopes(scopes ...func(*gorm.DB) *gorm.DB) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, scope := range scopes {
+			db = scope(db)
+		}
+		return db
+	}
+}
+
+// enforceExtensions lets the write extensions set their values on a record
+// written by the processor.
+func (p *DefaultProcessor) enforceExtensions(c *fiber.Ctx, instance interface{}) error {
+	var writers []WriteExtension
+	for _, extension := range queryExtensions(c) {
+		if writer, ok := extension.(WriteExtension); ok {
+			writers = append(writers, writer)
+		}
+	}
+	if len(writers) == 0 {
+		return nil
+	}
+
+	modelSchema, err := parseSchema(p.DB, instance)
+	if err != nil {
+		return err
+	}
+	for _, writer := range writers {
+		if err := writer.Enforce(c, modelSchema, instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package state
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// whereExtension scopes every query with a fixed condition.
+func whereExtension(query string, args ...interface{}) QueryExtension {
+	return QueryExtensionFunc(func(*fiber.Ctx, *schema.Schema) (func(*gorm.DB) *gorm.DB, error) {
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where(query, args...)
+		}, nil
+	})
+}
+
+// namingExtension prefixes the names of the records written by the
+// processor, refusing the names containing forbidden.
+type namingExtension struct {
+	QueryExtension
+	prefix    string
+	forbidden string
+}
+
+func (e namingExtension) Enforce(_ *fiber.Ctx, _ *schema.Schema, instance interface{}) error {
+	model := instance.(*TestModel)
+	if e.forbidden != "" && strings.Contains(model.Name, e.forbidden) {
+		return NewBadRequestError("forbidden name")
+	}
+	if !strings.HasPrefix(model.Name, e.prefix) {
+		model.Name = e.prefix + model.Name
+	}
+	return nil
+}
+
+type extendedPage struct {
+	Items      []TestModel `json:"items"`
+	TotalItems int64       `json:"totalItems"`
+}
+
+func fetchPage(t *testing.T, app *fiber.App, target string) (int, extendedPage) {
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	require.NoError(t, err)
+
+	var page extendedPage
+	if resp.StatusCode == fiber.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	}
+	return resp.StatusCode, page
+}
+
+// setupExtendedProvider serves 7 records through the provider with the
+// extensions set in context.
+func setupExtendedProvider(t *testing.T, pagination *PaginationConfig, extensions ...QueryExtension) *fiber.App {
+	provider, app := setupTestListProvider(t, 7)
+	handler := func(c *fiber.Ctx) error {
+		c.Locals("model", &TestModel{})
+		c.Locals("queryExtensions", extensions)
+		if pagination != nil {
+			c.Locals("pagination", *pagination)
+		}
+		data, err := provider.Provide(c)
+		if err != nil {
+			return err
+		}
+		return c.JSON(data)
+	}
+	app.Get("/items", handler)
+	app.Get("/items/:id", handler)
+	return app
+}
+
+func TestQueryExtensions(t *testing.T) {
+	odd := whereExtension("id % 2 = 1")
+	notFirst := whereExtension("id <> ?", 1)
+
+	t.Run("Scopes collections and their totals", func(t *testing.T) {
+		app := setupExtendedProvider(t, &PaginationConfig{DefaultPageSize: 2}, odd, notFirst)
+
+		status, page := fetchPage(t, app, "/items")
+		require.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, []uint{3, 5}, itemIDs(page.Items))
+		assert.Equal(t, int64(3), page.TotalItems)
+	})
+
+	t.Run("Scopes cursor pages", func(t *testing.T) {
+		config := &PaginationConfig{Mode: PaginationModeCursor, DefaultPageSize: 2, CursorSecret: []byte("test-secret")}
+		app := setupExtendedProvider(t, config, odd, notFirst)
+
+		_, first := fetchCursorPage(t, app, "/items")
+		assert.Equal(t, []uint{3, 5}, itemIDs(first.Items))
+		require.NotEmpty(t, first.Next)
+
+		_, second := fetchCursorPage(t, app, first.Next)
+		assert.Equal(t, []uint{7}, itemIDs(second.Items))
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("Hides records outside the scope", func(t *testing.T) {
+		app := setupExtendedProvider(t, nil, odd, notFirst)
+
+		for target, status := range map[string]int{
+			"/items/3": fiber.StatusOK,
+			"/items/1": fiber.StatusNotFound,
+			"/items/4": fiber.StatusNotFound,
+		} {
+			resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+			require.NoError(t, err)
+			assert.Equal(t, status, resp.StatusCode, target)
+		}
+	})
+
+	t.Run("Ignores extensions without scope", func(t *testing.T) {
+		none := QueryExtensionFunc(func(*fiber.Ctx, *schema.Schema) (func(*gorm.DB) *gorm.DB, error) {
+			return nil, nil
+		})
+		app := setupExtendedProvider(t, &PaginationConfig{}, none)
+
+		_, page := fetchPage(t, app, "/items")
+		assert.Equal(t, int64(7), page.TotalItems)
+	})
+
+	t.Run("Aborts on extension errors", func(t *testing.T) {
+		failing := QueryExtensionFunc(func(*fiber.Ctx, *schema.Schema) (func(*gorm.DB) *gorm.DB, error) {
+			return nil, NewForbiddenError("no scope")
+		})
+		app := setupExtendedProvider(t, nil, odd, failing)
+
+		for _, target := range []string{"/items", "/items/3"} {
+			resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusForbidden, resp.StatusCode, target)
+		}
+	})
+
+	t.Run("Enforces write extensions on created and updated records", func(t *testing.T) {
+		provider, app := setupTestListProvider(t, 1)
+		processor := &DefaultProcessor{DB: provider.DB}
+		extensions := []QueryExtension{odd, namingExtension{QueryExtension: odd, prefix: "acme: ", forbidden: "secret"}}
+
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("model", &TestModel{})
+			c.Locals("queryExtensions", extensions)
+			return c.Next()
+		})
+		app.Post("/items", func(c *fiber.Ctx) error {
+			_, err := processor.Process(c, nil)
+			return err
+		})
+		app.Put("/items/:id", func(c *fiber.Ctx) error {
+			data, err := provider.Provide(c)
+			if err != nil {
+				return err
+			}
+			_, err = processor.Process(c, data)
+			return err
+		})
+
+		for method, target := range map[string]string{"POST": "/items", "PUT": "/items/1"} {
+			req := httptest.NewRequest(method, target, strings.NewReader(`{"name":"Report"}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusOK, resp.StatusCode, method)
+		}
+
+		req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"secret"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "refused records are not written")
+
+		var names []string
+		require.NoError(t, provider.DB.(*gorm.DB).Model(&TestModel{}).Order("id").Pluck("name", &names).Error)
+		assert.Equal(t, []string{"acme: Report", "acme: Report"}, names)
+	})
+}
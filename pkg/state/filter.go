@@ -0,0 +1,306 @@
+package state
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/n3crone/gapi-platform/pkg/serializer"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// FilterStrategy defines how a query parameter is matched against a field.
+type FilterStrategy string
+
+// Supported filter strategies and the query syntax they accept
+const (
+	FilterExact   FilterStrategy = "exact"   // ?name=value
+	FilterPartial FilterStrategy = "partial" // ?name=val (LIKE %val%)
+	FilterRange   FilterStrategy = "range"   // ?price[gt]=1&price[lte]=9 (gt, gte, lt, lte)
+	FilterIn      FilterStrategy = "in"      // ?status[]=a&status[]=b or ?status=a,b
+	FilterBoolean FilterStrategy = "boolean" // ?active=true
+	FilterDate    FilterStrategy = "date"    // ?createdAt[after]=2024-01-01 (before, strictly_before, after, strictly_after)
+	FilterNull    FilterStrategy = "null"    // ?deletedAt[null]=true
+)
+
+// Filter declares a collection filter bound to a model field. Only declared
+// filters are applied; other query parameters never reach the database.
+type Filter struct {
+	Field    string         // Model field or column name to filter on
+	Strategy FilterStrategy // Matching strategy
+	Param    string         // Query parameter name, defaults to the field's JSON name
+}
+
+// filterParam is a query parameter split into its name and bracketed operator,
+// e.g. "price[gte]" becomes {name: "price", operator: "gte"}.
+type filterParam struct {
+	name     string
+	operator string
+	value    string
+}
+
+// parseFilterParams splits every query parameter into name, operator and value.
+func parseFilterParams(c *fiber.Ctx) []filterParam {
+	var params []filterParam
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		param := filterParam{name: string(key), value: string(value)}
+		if open := strings.IndexByte(param.name, '['); open > 0 && strings.HasSuffix(param.name, "]") {
+			param.operator = param.name[open+1 : len(param.name)-1]
+			param.name = param.name[:open]
+		}
+		params = append(params, param)
+	})
+	return params
+}
+
+// filterScope translates the declared filters present in the query string
+// into GORM conditions. Values are converted to the field type so that
+// malformed input is rejected with 400 instead of reaching the database.
+func (p *DefaultProvider) filterScope(c *fiber.Ctx, modelType interface{}) (func(*gorm.DB) *gorm.DB, error) {
+	filters, _ := c.Locals("filters").([]Filter)
+	if len(filters) == 0 {
+		return noopScope, nil
+	}
+
+	modelSchema, err := parseSchema(p.DB, modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	byParam := make(map[string]Filter, len(filters))
+	fields := make(map[string]*schema.Field, len(filters))
+	for _, filter := range filters {
+		field := modelSchema.LookUpField(filter.Field)
+		if field == nil || field.DBName == "" {
+			return nil, NewInternalError("invalid filter field "+filter.Field, nil)
+		}
+
+		param := filter.Param
+		if param == "" {
+			param = serializer.JSONName(field)
+		}
+		byParam[param] = filter
+		fields[param] = field
+	}
+
+	var conditions []clause.Expression
+	inValues := make(map[string][]interface{})
+	for _, param := range parseFilterParams(c) {
+		filter, declared := byParam[param.name]
+		if !declared {
+			continue
+		}
+		field := fields[param.name]
+
+		if filter.Strategy == FilterIn {
+			values, err := parseInValues(field, param)
+			if err != nil {
+				return nil, err
+			}
+			inValues[param.name] = append(inValues[param.name], values...)
+			continue
+		}
+
+		condition, err := filterCondition(filter.Strategy, field, param)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	for name, values := range inValues {
+		conditions = append(conditions, clause.IN{Column: fieldColumn(fields[name]), Values: values})
+	}
+
+	if len(conditions) == 0 {
+		return noopScope, nil
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.And(conditions...))
+	}, nil
+}
+
+// filterCondition builds the condition for a single non-list filter parameter.
+func filterCondition(strategy FilterStrategy, field *schema.Field, param filterParam) (clause.Expression, error) {
+	column := fieldColumn(field)
+
+	switch strategy {
+	case FilterBoolean:
+		value, err := strconv.ParseBool(param.value)
+		if err != nil || param.operator != "" {
+			return nil, invalidFilter(param)
+		}
+		return clause.Eq{Column: column, Value: value}, nil
+
+	case FilterExact:
+		if param.operator != "" {
+			return nil, invalidFilter(param)
+		}
+		value, err := convertFilterValue(field, param)
+		if err != nil {
+			return nil, err
+		}
+		return clause.Eq{Column: column, Value: value}, nil
+
+	case FilterPartial:
+		if param.operator != "" {
+			return nil, invalidFilter(param)
+		}
+		return clause.Expr{
+			SQL:  "? LIKE ? ESCAPE '!'",
+			Vars: []interface{}{column, "%" + likeEscaper.Replace(param.value) + "%"},
+		}, nil
+
+	case FilterRange:
+		value, err := convertFilterValue(field, param)
+		if err != nil {
+			return nil, err
+		}
+		switch param.operator {
+		case "gt":
+			return clause.Gt{Column: column, Value: value}, nil
+		case "gte":
+			return clause.Gte{Column: column, Value: value}, nil
+		case "lt":
+			return clause.Lt{Column: column, Value: value}, nil
+		case "lte":
+			return clause.Lte{Column: column, Value: value}, nil
+		}
+
+	case FilterDate:
+		value, err := parseFilterDate(param)
+		if err != nil {
+			return nil, err
+		}
+		// A plain date stands for the whole day: before and strictly_after
+		// compare with the start of the next day
+		next, isDay := value.AddDate(0, 0, 1), len(param.value) == len(time.DateOnly)
+		switch param.operator {
+		case "after":
+			return clause.Gte{Column: column, Value: value}, nil
+		case "strictly_after":
+			if isDay {
+				return clause.Gte{Column: column, Value: next}, nil
+			}
+			return clause.Gt{Column: column, Value: value}, nil
+		case "before":
+			if isDay {
+				return clause.Lt{Column: column, Value: next}, nil
+			}
+			return clause.Lte{Column: column, Value: value}, nil
+		case "strictly_before":
+			return clause.Lt{Column: column, Value: value}, nil
+		}
+
+	case FilterNull:
+		isNull, err := strconv.ParseBool(param.value)
+		if err != nil || param.operator != "null" {
+			return nil, invalidFilter(param)
+		}
+		if isNull {
+			return clause.Expr{SQL: "? IS NULL", Vars: []interface{}{column}}, nil
+		}
+		return clause.Expr{SQL: "? IS NOT NULL", Vars: []interface{}{column}}, nil
+	}
+
+	return nil, invalidFilter(param)
+}
+
+// parseInValues reads list values from name[]=a or comma separated name=a,b.
+func parseInValues(field *schema.Field, param filterParam) ([]interface{}, error) {
+	if param.operator != "" {
+		return nil, invalidFilter(param)
+	}
+
+	var values []interface{}
+	for _, raw := range strings.Split(param.value, ",") {
+		value, err := convertFilterValue(field, filterParam{name: param.name, value: raw})
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// convertFilterValue converts a raw query value to the field's Go type.
+func convertFilterValue(field *schema.Field, param filterParam) (interface{}, error) {
+	value, ok := parseFieldValue(field, param.value)
+	if !ok {
+		return nil, invalidFilter(param)
+	}
+	return value, nil
+}
+
+// parseFieldValue converts a raw string to the field's Go type, reporting
+// whether it is valid for that type. Other types are left to the database
+// as strings.
+func parseFieldValue(field *schema.Field, raw string) (interface{}, bool) {
+	fieldType := field.FieldType
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		return value, err == nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(raw, 10, fieldType.Bits())
+		return value, err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(raw, 10, fieldType.Bits())
+		return value, err == nil
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(raw, fieldType.Bits())
+		return value, err == nil
+	case reflect.Struct:
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			return parseDate(raw)
+		}
+	}
+	return raw, true
+}
+
+// likeEscaper escapes the LIKE wildcards so that partial filters match them
+// literally. The escape character is "!" rather than a backslash, which
+// MySQL would read as escaping the closing quote of the ESCAPE clause.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// parseFilterDate accepts RFC 3339 timestamps or plain YYYY-MM-DD dates.
+func parseFilterDate(param filterParam) (time.Time, error) {
+	value, ok := parseDate(param.value)
+	if !ok {
+		return time.Time{}, invalidFilter(param)
+	}
+	return value, nil
+}
+
+// parseDate accepts RFC 3339 timestamps or plain YYYY-MM-DD dates.
+func parseDate(raw string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if value, err := time.Parse(layout, raw); err == nil {
+			return value, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func invalidFilter(param filterParam) error {
+	return NewBadRequestError("invalid value for filter " + param.name)
+}
+
+// fieldColumn returns a column reference for the field on the current table.
+func fieldColumn(field *schema.Field) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+}
+
+func noopScope(db *gorm.DB) *gorm.DB {
+	return db
+}
@@ -0,0 +1,208 @@
+package state
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type FilterModel struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	Name        string     `json:"name"`
+	Price       float64    `json:"price"`
+	Status      string     `json:"status"`
+	Active      bool       `json:"active"`
+	Secret      string     `json:"secret"`
+	PublishedAt *time.Time `json:"publishedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+var testFilters = []Filter{
+	{Field: "Name", Strategy: FilterPartial},
+	{Field: "Status", Strategy: FilterExact},
+	{Field: "Status", Strategy: FilterIn, Param: "statuses"},
+	{Field: "Price", Strategy: FilterRange},
+	{Field: "Active", Strategy: FilterBoolean},
+	{Field: "created_at", Strategy: FilterDate},
+	{Field: "PublishedAt", Strategy: FilterNull},
+}
+
+// setupFilterApp registers a list route with the test filters declared over
+// a small catalogue of products.
+func setupFilterApp(t *testing.T, filters []Filter) *fiber.App {
+	return setupCatalogApp(t, map[string]interface{}{"filters": filters})
+}
+
+// setupCatalogApp registers a list route over a small catalogue of products
+// with the given collection settings stored in the context.
+func setupCatalogApp(t *testing.T, locals map[string]interface{}) *fiber.App {
+	published := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	return setupProductsApp(t, locals,
+		FilterModel{Name: "Red shirt", Price: 10, Status: "draft", Active: true, CreatedAt: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)},
+		FilterModel{Name: "Blue shirt", Price: 20, Status: "published", Active: false, PublishedAt: &published, CreatedAt: time.Date(2024, 2, 10, 15, 30, 0, 0, time.UTC)},
+		FilterModel{Name: "Red hat", Price: 30, Status: "archived", Active: true, PublishedAt: &published, CreatedAt: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+	)
+}
+
+// setupProductsApp registers a list route over the given products with the
+// collection settings stored in the context.
+func setupProductsApp(t *testing.T, locals map[string]interface{}, records ...FilterModel) *fiber.App {
+	db := testutils.NewTestDB(t, &FilterModel{})
+	for i := range records {
+		require.NoError(t, db.Create(&records[i]).Error)
+	}
+
+	provider := &DefaultProvider{DB: db}
+	app := fiber.New()
+	app.Get("/products", func(c *fiber.Ctx) error {
+		c.Locals("model", &FilterModel{})
+		for key, value := range locals {
+			c.Locals(key, value)
+		}
+		data, err := provider.Provide(c)
+		if err != nil {
+			return err
+		}
+		return c.JSON(data)
+	})
+
+	return app
+}
+
+func filterNames(t *testing.T, app *fiber.App, target string) (int, []string, int64) {
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	require.NoError(t, err)
+	if resp.StatusCode != fiber.StatusOK {
+		return resp.StatusCode, nil, 0
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var collection struct {
+		Items      []FilterModel `json:"items"`
+		TotalItems int64         `json:"totalItems"`
+	}
+	require.NoError(t, json.Unmarshal(body, &collection))
+
+	names := make([]string, len(collection.Items))
+	for i, item := range collection.Items {
+		names[i] = item.Name
+	}
+	return resp.StatusCode, names, collection.TotalItems
+}
+
+func TestFilters(t *testing.T) {
+	app := setupFilterApp(t, testFilters)
+
+	tests := []struct {
+		name      string
+		target    string
+		want      []string
+		wantTotal int64
+	}{
+		{"No filters", "/products", []string{"Red shirt", "Blue shirt", "Red hat"}, 3},
+		{"Partial match", "/products?name=shirt", []string{"Red shirt", "Blue shirt"}, 2},
+		{"Exact match", "/products?status=draft", []string{"Red shirt"}, 1},
+		{"In list with brackets", "/products?statuses[]=draft&statuses[]=archived", []string{"Red shirt", "Red hat"}, 2},
+		{"In list comma separated", "/products?statuses=published,archived", []string{"Blue shirt", "Red hat"}, 2},
+		{"Range", "/products?price[gt]=10&price[lte]=30", []string{"Blue shirt", "Red hat"}, 2},
+		{"Boolean", "/products?active=false", []string{"Blue shirt"}, 1},
+		{"Date after", "/products?createdAt[after]=2024-02-01", []string{"Blue shirt", "Red hat"}, 2},
+		{"Date strictly before", "/products?createdAt[strictly_before]=2024-02-10T00:00:00Z", []string{"Red shirt"}, 1},
+		{"Date before includes the whole day", "/products?createdAt[before]=2024-02-10", []string{"Red shirt", "Blue shirt"}, 2},
+		{"Date strictly before excludes the whole day", "/products?createdAt[strictly_before]=2024-02-10", []string{"Red shirt"}, 1},
+		{"Date after includes the whole day", "/products?createdAt[after]=2024-02-10", []string{"Blue shirt", "Red hat"}, 2},
+		{"Date strictly after excludes the whole day", "/products?createdAt[strictly_after]=2024-02-10", []string{"Red hat"}, 1},
+		{"Timestamp before", "/products?createdAt[before]=2024-02-10T15:00:00Z", []string{"Red shirt"}, 1},
+		{"Is null", "/products?publishedAt[null]=true", []string{"Red shirt"}, 1},
+		{"Is not null", "/products?publishedAt[null]=false", []string{"Blue shirt", "Red hat"}, 2},
+		{"Combined filters", "/products?name=red&active=true&price[lt]=20", []string{"Red shirt"}, 1},
+		{"Undeclared field is ignored", "/products?secret=x&id=2", []string{"Red shirt", "Blue shirt", "Red hat"}, 3},
+		{"Composes with pagination", "/products?name=red&itemsPerPage=1&page=2", []string{"Red hat"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, names, total := filterNames(t, app, tt.target)
+			require.Equal(t, fiber.StatusOK, status)
+			assert.Equal(t, tt.want, names)
+			assert.Equal(t, tt.wantTotal, total)
+		})
+	}
+
+	t.Run("Partial match escapes wildcards", func(t *testing.T) {
+		app := setupProductsApp(t, map[string]interface{}{"filters": testFilters},
+			FilterModel{Name: "100% cotton"},
+			FilterModel{Name: "1000 cotton"},
+			FilterModel{Name: "cotton_blend"},
+			FilterModel{Name: `cotton\silk`},
+			FilterModel{Name: "cotton!wool"},
+		)
+
+		for target, want := range map[string][]string{
+			"/products?name=0%25":      {"100% cotton"},
+			"/products?name=n_":        {"cotton_blend"},
+			"/products?name=%5Cs":      {`cotton\silk`},
+			"/products?name=!w":        {"cotton!wool"},
+			"/products?name=%25":       {"100% cotton"},
+			"/products?name=cotton%25": nil,
+		} {
+			status, names, _ := filterNames(t, app, target)
+			require.Equal(t, fiber.StatusOK, status, target)
+			assert.ElementsMatch(t, want, names, target)
+		}
+	})
+
+	t.Run("Partial match escapes portably", func(t *testing.T) {
+		db, err := gorm.Open(mysql.New(mysql.Config{
+			DSN:                       "user@tcp(localhost:3306)/shop",
+			SkipInitializeWithVersion: true,
+		}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+		require.NoError(t, err)
+		modelSchema, err := parseSchema(db, &FilterModel{})
+		require.NoError(t, err)
+
+		condition, err := filterCondition(FilterPartial, modelSchema.LookUpField("Name"), filterParam{name: "name", value: "5%_!"})
+		require.NoError(t, err)
+
+		stmt := db.Where(condition).Find(&[]FilterModel{}).Statement
+		assert.Equal(t, "SELECT * FROM `filter_models` WHERE `filter_models`.`name` LIKE ? ESCAPE '!'", stmt.SQL.String())
+		assert.Equal(t, []interface{}{"%5!%!_!!%"}, stmt.Vars)
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		targets := []string{
+			"/products?price[gt]=cheap",
+			"/products?price[between]=1..2",
+			"/products?price=10",
+			"/products?active=maybe",
+			"/products?createdAt[after]=yesterday",
+			"/products?createdAt=2024-01-01",
+			"/products?publishedAt[null]=perhaps",
+			"/products?status[gt]=a",
+		}
+
+		for _, target := range targets {
+			status, _, _ := filterNames(t, app, target)
+			assert.Equal(t, fiber.StatusBadRequest, status, target)
+		}
+	})
+
+	t.Run("Unknown declared field", func(t *testing.T) {
+		app := setupFilterApp(t, []Filter{{Field: "Missing", Strategy: FilterExact}})
+
+		status, _, _ := filterNames(t, app, "/products?missing=1")
+		assert.Equal(t, fiber.StatusInternalServerError, status)
+	})
+}
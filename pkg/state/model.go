@@ -0,0 +1,15 @@
+package state
+
+import "reflect"
+
+// newInstance allocates a new zero value of the model's struct type.
+// The model must be a pointer to a struct, as guaranteed by validateModel.
+func newInstance(model interface{}) interface{} {
+	return reflect.New(reflect.TypeOf(model).Elem()).Interface()
+}
+
+// newSlice allocates a pointer to a new empty slice of the model's struct type.
+// The model must be a pointer to a struct, as guaranteed by validateModel.
+func newSlice(model interface{}) interface{} {
+	return reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem())).Interface()
+}
@@ -0,0 +1,109 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/n3crone/gapi-platform/pkg/serializer"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderConfig declares how collections may be sorted. Clients sort with
+// ?order[field]=asc|desc using the field's JSON name; fields missing from
+// Fields are rejected.
+type OrderConfig struct {
+	Fields  []string  // Sortable model fields or column names
+	Default []OrderBy // Order applied when the client does not request one
+}
+
+// OrderBy sorts a collection by a single field.
+type OrderBy struct {
+	Field string // Model field or column name
+	Desc  bool   // Sort in descending order
+}
+
+// orderScope translates order[field] query parameters, or the configured
+// default order, into ORDER BY clauses. The primary key is always appended
+// as a tiebreaker so that pagination stays stable.
+func (p *DefaultProvider) orderScope(c *fiber.Ctx, modelType interface{}) (func(*gorm.DB) *gorm.DB, error) {
+	config, _ := c.Locals("order").(OrderConfig)
+
+	modelSchema, err := parseSchema(p.DB, modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	sortable := make(map[string]string, len(config.Fields))
+	for _, name := range config.Fields {
+		field := modelSchema.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, NewInternalError("invalid sortable field "+name, nil)
+		}
+		sortable[serializer.JSONName(field)] = field.DBName
+	}
+
+	var columns []clause.OrderByColumn
+	for _, param := range parseFilterParams(c) {
+		if param.name != "order" {
+			continue
+		}
+		if param.operator == "" {
+			return nil, NewBadRequestError("invalid order parameter: expected order[field]=asc|desc")
+		}
+
+		column, allowed := sortable[param.operator]
+		if !allowed {
+			return nil, NewBadRequestError("cannot order by " + param.operator + ": field is not sortable")
+		}
+
+		var desc bool
+		switch strings.ToLower(param.value) {
+		case "asc", "":
+		case "desc":
+			desc = true
+		default:
+			return nil, NewBadRequestError("invalid order direction for " + param.operator + ": expected asc or desc")
+		}
+
+		columns = append(columns, clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: column},
+			Desc:   desc,
+		})
+	}
+
+	if len(columns) == 0 {
+		for _, order := range config.Default {
+			field := modelSchema.LookUpField(order.Field)
+			if field == nil || field.DBName == "" {
+				return nil, NewInternalError("invalid default order field "+order.Field, nil)
+			}
+			columns = append(columns, clause.OrderByColumn{Column: fieldColumn(field), Desc: order.Desc})
+		}
+	}
+
+	if primary := modelSchema.PrioritizedPrimaryField; primary != nil {
+		ordered := false
+		for _, column := range columns {
+			ordered = ordered || column.Column.Name == primary.DBName
+		}
+		if !ordered {
+			columns = append(columns, clause.OrderByColumn{Column: fieldColumn(primary)})
+		}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(clause.OrderBy{Columns: columns})
+	}, nil
+}
+
+// hasOrderParams reports whether the client requested an explicit order.
+func hasOrderParams(c *fiber.Ctx) bool {
+	for _, param := range parseFilterParams(c) {
+		if param.name == "order" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package state
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrder(t *testing.T) {
+	order := OrderConfig{
+		Fields:  []string{"Name", "Price", "Active", "created_at"},
+		Default: []OrderBy{{Field: "Price", Desc: true}},
+	}
+	app := setupCatalogApp(t, map[string]interface{}{
+		"filters": testFilters,
+		"order":   order,
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		want   []string
+	}{
+		{"Default order", "/products", []string{"Red hat", "Blue shirt", "Red shirt"}},
+		{"Single field ascending", "/products?order[name]=asc", []string{"Blue shirt", "Red hat", "Red shirt"}},
+		{"Single field descending", "/products?order[createdAt]=DESC", []string{"Red hat", "Blue shirt", "Red shirt"}},
+		{"Multiple fields in query order", "/products?order[active]=desc&order[name]=desc", []string{"Red shirt", "Red hat", "Blue shirt"}},
+		{"Composes with filters", "/products?name=shirt&order[price]=desc", []string{"Blue shirt", "Red shirt"}},
+		{"Composes with pagination", "/products?order[name]=asc&itemsPerPage=2&page=2", []string{"Red shirt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, names, _ := filterNames(t, app, tt.target)
+			require.Equal(t, fiber.StatusOK, status)
+			assert.Equal(t, tt.want, names)
+		})
+	}
+
+	t.Run("Rejects disallowed fields and directions", func(t *testing.T) {
+		tests := []struct {
+			target string
+			want   string
+		}{
+			{"/products?order[secret]=asc", "cannot order by secret: field is not sortable"},
+			{"/products?order[unknown]=asc", "cannot order by unknown: field is not sortable"},
+			{"/products?order=name", "invalid order parameter: expected order[field]=asc|desc"},
+			{"/products?order[name]=sideways", "invalid order direction for name: expected asc or desc"},
+		}
+
+		for _, tt := range tests {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.target, nil))
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, tt.target)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(body))
+		}
+	})
+
+	t.Run("Rejects order without sortable fields", func(t *testing.T) {
+		app := setupCatalogApp(t, nil)
+
+		status, _, _ := filterNames(t, app, "/products?order[name]=asc")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+
+	t.Run("Rejects order with cursor pagination", func(t *testing.T) {
+		app := setupCatalogApp(t, map[string]interface{}{
+			"order":      order,
+			"pagination": PaginationConfig{Mode: PaginationModeCursor},
+		})
+
+		status, _, _ := filterNames(t, app, "/products?order[name]=asc")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+}
@@ -0,0 +1,191 @@
+package state
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Default page sizes applied when a PaginationConfig leaves them unset.
+const (
+	DefaultPageSize    = 30
+	DefaultMaxPageSize = 100
+)
+
+// maxOffset bounds the requested offset, so that page arithmetic cannot
+// overflow and the value fits the OFFSET clause of every database.
+const maxOffset = math.MaxInt32
+
+// PaginationMode selects the strategy used to page through collections.
+type PaginationMode string
+
+// Supported pagination modes
+const (
+	PaginationModeOffset PaginationMode = "offset" // page/itemsPerPage or offset/limit (default)
+	PaginationModeCursor PaginationMode = "cursor" // Opaque signed cursor over an ordered unique key
+)
+
+// PaginationConfig controls how collection queries are paginated.
+// The zero value enables page-based pagination with the default sizes.
+type PaginationConfig struct {
+	Disabled        bool           // Return the whole collection as a plain array
+	Mode            PaginationMode // Pagination strategy, defaults to PaginationModeOffset
+	DefaultPageSize int            // Items per page when the client does not specify one
+	MaxPageSize     int            // Upper bound for client-requested page sizes
+	CursorKey       string         // Ordered unique column used in cursor mode, defaults to the primary key
+	CursorSecret    []byte         // HMAC key used to sign cursors, required in cursor mode and shared by all instances
+}
+
+// Validate reports settings that cannot serve requests, such as cursor
+// pagination without a CursorSecret.
+func (c PaginationConfig) Validate() error {
+	if c.Mode == PaginationModeCursor && !c.Disabled && len(c.CursorSecret) == 0 {
+		return errors.New("cursor pagination requires a CursorSecret")
+	}
+	return nil
+}
+
+// Collection is the response envelope returned for paginated collections.
+type Collection struct {
+	Items        interface{} `json:"items"`              // Slice of records for the current page
+	TotalItems   int64       `json:"totalItems"`         // Number of records matching the query
+	Page         int         `json:"page"`               // Current page number, starting at 1; rounded down for offsets between pages
+	ItemsPerPage int         `json:"itemsPerPage"`       // Page size used for this response
+	Next         string      `json:"next,omitempty"`     // Link to the next page, if any
+	Previous     string      `json:"previous,omitempty"` // Link to the previous page, if any
+}
+
+// pageRequest is the resolved window of a collection requested by the client.
+type pageRequest struct {
+	limit    int
+	offset   int
+	byOffset bool // client used offset/limit instead of page/itemsPerPage
+}
+
+// paginationConfig returns the pagination settings stored in the context
+// with defaults applied for unset page sizes.
+func paginationConfig(c *fiber.Ctx) PaginationConfig {
+	config, _ := c.Locals("pagination").(PaginationConfig)
+
+	if config.MaxPageSize <= 0 {
+		config.MaxPageSize = DefaultMaxPageSize
+	}
+	if config.DefaultPageSize <= 0 {
+		config.DefaultPageSize = DefaultPageSize
+	}
+	if config.DefaultPageSize > config.MaxPageSize {
+		config.DefaultPageSize = config.MaxPageSize
+	}
+
+	return config
+}
+
+// parsePageRequest reads either page/itemsPerPage or offset/limit query
+// parameters. Page sizes above the configured maximum are clamped; pages and
+// offsets beyond maxOffset are rejected.
+func parsePageRequest(c *fiber.Ctx, config PaginationConfig) (pageRequest, error) {
+	req := pageRequest{limit: config.DefaultPageSize}
+
+	sizeParam := "itemsPerPage"
+	if c.Query("offset") != "" || c.Query("limit") != "" {
+		req.byOffset = true
+		sizeParam = "limit"
+	}
+
+	limit, err := parsePageSize(c, config, sizeParam)
+	if err != nil {
+		return req, err
+	}
+	req.limit = limit
+
+	if req.byOffset {
+		if raw := c.Query("offset"); raw != "" {
+			offset, err := strconv.Atoi(raw)
+			if err != nil || offset < 0 || offset > maxOffset {
+				return req, NewBadRequestError("invalid offset parameter")
+			}
+			req.offset = offset
+		}
+		return req, nil
+	}
+
+	if raw := c.Query("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 || page-1 > maxOffset/req.limit {
+			return req, NewBadRequestError("invalid page parameter")
+		}
+		req.offset = (page - 1) * req.limit
+	}
+
+	return req, nil
+}
+
+// parsePageSize reads the page size from the given query parameter,
+// falling back to the configured default and clamping to the maximum.
+func parsePageSize(c *fiber.Ctx, config PaginationConfig, param string) (int, error) {
+	raw := c.Query(param)
+	if raw == "" {
+		return config.DefaultPageSize, nil
+	}
+
+	size, err := strconv.Atoi(raw)
+	if err != nil || size < 1 {
+		return 0, NewBadRequestError("invalid " + param + " parameter")
+	}
+
+	return min(size, config.MaxPageSize), nil
+}
+
+// scope limits a query to the requested window.
+func (r pageRequest) scope(db *gorm.DB) *gorm.DB {
+	return db.Limit(r.limit).Offset(r.offset)
+}
+
+// newCollection wraps a page of results in a Collection envelope with
+// next/previous links that preserve the remaining query parameters.
+func newCollection(c *fiber.Ctx, items interface{}, total int64, req pageRequest) *Collection {
+	collection := &Collection{
+		Items:        items,
+		TotalItems:   total,
+		Page:         req.offset/req.limit + 1,
+		ItemsPerPage: req.limit,
+	}
+
+	if int64(req.offset+req.limit) < total {
+		collection.Next = pageLink(c, req, req.offset+req.limit)
+	}
+	if req.offset > 0 {
+		collection.Previous = pageLink(c, req, max(req.offset-req.limit, 0))
+	}
+
+	return collection
+}
+
+// pageLink builds a link to the page starting at offset using the same
+// parameter style the client used.
+func pageLink(c *fiber.Ctx, req pageRequest, offset int) string {
+	query := currentQuery(c)
+
+	if req.byOffset {
+		query.Set("offset", strconv.Itoa(offset))
+		query.Set("limit", strconv.Itoa(req.limit))
+	} else {
+		query.Set("page", strconv.Itoa(offset/req.limit+1))
+		query.Set("itemsPerPage", strconv.Itoa(req.limit))
+	}
+
+	return c.Path() + "?" + query.Encode()
+}
+
+// currentQuery returns a copy of the request query parameters.
+func currentQuery(c *fiber.Ctx) url.Values {
+	query := url.Values{}
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		query.Add(string(key), string(value))
+	})
+	return query
+}
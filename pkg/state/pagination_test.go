@@ -0,0 +1,124 @@
+package state
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestCollection performs a GET against a provider-backed list route
+// configured with the given pagination settings and decodes the envelope.
+func requestCollection(t *testing.T, config PaginationConfig, target string) (int, map[string]interface{}) {
+	provider, app := setupTestListProvider(t, 25)
+
+	app.Get("/items", func(c *fiber.Ctx) error {
+		c.Locals("model", &TestModel{})
+		c.Locals("pagination", config)
+		data, err := provider.Provide(c)
+		if err != nil {
+			return err
+		}
+		return c.JSON(data)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var result map[string]interface{}
+	if resp.StatusCode == fiber.StatusOK {
+		require.NoError(t, json.Unmarshal(body, &result))
+	}
+	return resp.StatusCode, result
+}
+
+func TestPagination(t *testing.T) {
+	t.Run("Applies default page size", func(t *testing.T) {
+		status, result := requestCollection(t, PaginationConfig{DefaultPageSize: 10}, "/items")
+		require.Equal(t, fiber.StatusOK, status)
+
+		assert.Len(t, result["items"], 10)
+		assert.EqualValues(t, 25, result["totalItems"])
+		assert.EqualValues(t, 1, result["page"])
+		assert.EqualValues(t, 10, result["itemsPerPage"])
+		assert.Equal(t, "/items?itemsPerPage=10&page=2", result["next"])
+		assert.NotContains(t, result, "previous")
+	})
+
+	t.Run("Page and itemsPerPage parameters", func(t *testing.T) {
+		status, result := requestCollection(t, PaginationConfig{}, "/items?page=3&itemsPerPage=10&name=x")
+		require.Equal(t, fiber.StatusOK, status)
+
+		items := result["items"].([]interface{})
+		require.Len(t, items, 5)
+		assert.Equal(t, "Test 21", items[0].(map[string]interface{})["name"])
+		assert.EqualValues(t, 3, result["page"])
+		assert.Equal(t, "/items?itemsPerPage=10&name=x&page=2", result["previous"])
+		assert.NotContains(t, result, "next")
+	})
+
+	t.Run("Offset and limit parameters", func(t *testing.T) {
+		status, result := requestCollection(t, PaginationConfig{}, "/items?offset=5&limit=5")
+		require.Equal(t, fiber.StatusOK, status)
+
+		items := result["items"].([]interface{})
+		require.Len(t, items, 5)
+		assert.Equal(t, "Test 6", items[0].(map[string]interface{})["name"])
+		assert.EqualValues(t, 2, result["page"])
+		assert.Equal(t, "/items?limit=5&offset=10", result["next"])
+		assert.Equal(t, "/items?limit=5&offset=0", result["previous"])
+	})
+
+	t.Run("Clamps page size to maximum", func(t *testing.T) {
+		status, result := requestCollection(t, PaginationConfig{MaxPageSize: 8}, "/items?itemsPerPage=50")
+		require.Equal(t, fiber.StatusOK, status)
+
+		assert.Len(t, result["items"], 8)
+		assert.EqualValues(t, 8, result["itemsPerPage"])
+	})
+
+	t.Run("Disabled pagination returns plain array", func(t *testing.T) {
+		provider, app := setupTestListProvider(t, 3)
+
+		app.Get("/items", func(c *fiber.Ctx) error {
+			c.Locals("model", &TestModel{})
+			c.Locals("pagination", PaginationConfig{Disabled: true})
+			data, err := provider.Provide(c)
+			require.NoError(t, err)
+
+			models, ok := data.(*[]TestModel)
+			require.True(t, ok)
+			assert.Len(t, *models, 3)
+			return nil
+		})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/items?page=2", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		targets := []string{
+			"/items?page=0",
+			"/items?page=abc",
+			"/items?itemsPerPage=0",
+			"/items?offset=-1",
+			"/items?limit=abc",
+			"/items?offset=9223372036854775807",
+			"/items?page=9223372036854775807",
+			"/items?page=1000000000&itemsPerPage=10",
+		}
+
+		for _, target := range targets {
+			status, _ := requestCollection(t, PaginationConfig{}, target)
+			assert.Equal(t, fiber.StatusBadRequest, status, target)
+		}
+	})
+}
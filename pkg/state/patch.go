@@ -0,0 +1,336 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/n3crone/gapi-platform/pkg/serializer"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Media types accepted by PATCH requests
+const (
+	MergePatchContentType = "application/merge-patch+json" // RFC 7396 JSON Merge Patch
+	JSONPatchContentType  = "application/json-patch+json"  // RFC 6902 JSON Patch
+)
+
+// patchOperation is a single RFC 6902 operation.
+type patchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// applyPatch applies the request body to the JSON document of an entity
+// according to the request content type. Plain application/json bodies are
+// treated as merge patches.
+func applyPatch(c *fiber.Ctx, document []byte) ([]byte, error) {
+	var target interface{}
+	if err := decodeJSON(document, &target); err != nil {
+		return nil, NewInternalError("failed to encode record", err)
+	}
+
+	mediaType, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case MergePatchContentType, fiber.MIMEApplicationJSON:
+		var patch interface{}
+		if err := decodeJSON(c.Body(), &patch); err != nil {
+			return nil, NewBadRequestError("invalid merge patch document")
+		}
+		if _, ok := patch.(map[string]interface{}); !ok {
+			return nil, NewBadRequestError("merge patch document must be an object")
+		}
+		target = mergePatch(target, patch)
+
+	case JSONPatchContentType:
+		var operations []patchOperation
+		if err := json.Unmarshal(c.Body(), &operations); err != nil {
+			return nil, NewBadRequestError("invalid JSON patch document")
+		}
+		var err error
+		if target, err = jsonPatch(target, operations); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, &HTTPError{
+			Status: fiber.StatusUnsupportedMediaType,
+			Detail: "PATCH requires " + MergePatchContentType + " or " + JSONPatchContentType,
+		}
+	}
+
+	patched, err := json.Marshal(target)
+	if err != nil {
+		return nil, NewInternalError("failed to encode patched record", err)
+	}
+	return patched, nil
+}
+
+// mergePatch implements the RFC 7396 MergePatch algorithm: objects are merged
+// recursively, null removes a member and any other value replaces the target.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
+
+// jsonPatch applies RFC 6902 operations in order. The patch is atomic: any
+// failing operation aborts it and the target is left untouched.
+func jsonPatch(target interface{}, operations []patchOperation) (interface{}, error) {
+	for i, operation := range operations {
+		if operation.Path == nil {
+			return nil, NewBadRequestError("operation " + strconv.Itoa(i) + " is missing path")
+		}
+		path, err := parsePointer(*operation.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		switch operation.Op {
+		case "add", "replace", "test":
+			if operation.Value == nil {
+				return nil, NewBadRequestError("operation " + strconv.Itoa(i) + " is missing value")
+			}
+			if err := decodeJSON(*operation.Value, &value); err != nil {
+				return nil, NewBadRequestError("operation " + strconv.Itoa(i) + " has an invalid value")
+			}
+		case "move", "copy":
+			if operation.From == nil {
+				return nil, NewBadRequestError("operation " + strconv.Itoa(i) + " is missing from")
+			}
+			from, err := parsePointer(*operation.From)
+			if err != nil {
+				return nil, err
+			}
+			if value, err = getPointer(target, from); err != nil {
+				return nil, err
+			}
+			if operation.Op == "move" {
+				if strings.HasPrefix(*operation.Path+"/", *operation.From+"/") && *operation.Path != *operation.From {
+					return nil, NewValidationError("cannot move " + *operation.From + " into one of its children")
+				}
+				if target, err = removePointer(target, from); err != nil {
+					return nil, err
+				}
+			} else {
+				value = deepCopy(value)
+			}
+		case "remove":
+		default:
+			return nil, NewBadRequestError("operation " + strconv.Itoa(i) + " has unknown op " + strconv.Quote(operation.Op))
+		}
+
+		switch operation.Op {
+		case "add", "move", "copy":
+			target, err = addPointer(target, path, value)
+		case "remove":
+			target, err = removePointer(target, path)
+		case "replace":
+			if target, err = removePointer(target, path); err == nil {
+				target, err = addPointer(target, path, value)
+			}
+		case "test":
+			var current interface{}
+			if current, err = getPointer(target, path); err == nil && !reflect.DeepEqual(current, value) {
+				return nil, NewConflictError("test operation failed at "+*operation.Path, nil)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return target, nil
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, NewBadRequestError("invalid JSON pointer " + strconv.Quote(pointer))
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// getPointer returns the value referenced by the pointer tokens.
+func getPointer(document interface{}, tokens []string) (interface{}, error) {
+	current := document
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, missingPath(tokens)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, missingPath(tokens)
+		}
+	}
+	return current, nil
+}
+
+// addPointer inserts or replaces the value at the pointer and returns the
+// new document. Array members are inserted, "-" appends.
+func addPointer(document interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parent, err := getPointer(document, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return document, nil
+	case []interface{}:
+		index := len(node)
+		if last != "-" {
+			if index, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		updated := append(node[:index:index], append([]interface{}{value}, node[index:]...)...)
+		return addPointer(document, tokens[:len(tokens)-1], updated)
+	default:
+		return nil, missingPath(tokens)
+	}
+}
+
+// removePointer deletes the value at the pointer and returns the new document.
+func removePointer(document interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, NewValidationError("cannot remove the whole document")
+	}
+
+	parent, err := getPointer(document, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[last]; !ok {
+			return nil, missingPath(tokens)
+		}
+		delete(node, last)
+		return document, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated := append(node[:index:index], node[index+1:]...)
+		return addPointer(document, tokens[:len(tokens)-1], updated)
+	default:
+		return nil, missingPath(tokens)
+	}
+}
+
+// arrayIndex parses an array index token no greater than max.
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, NewValidationError("invalid array index " + strconv.Quote(token))
+	}
+	return index, nil
+}
+
+func missingPath(tokens []string) error {
+	return NewValidationError("path /" + strings.Join(tokens, "/") + " does not exist")
+}
+
+// deepCopy duplicates a decoded JSON value so that copies do not alias.
+func deepCopy(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(node))
+		for key, item := range node {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(node))
+		for i, item := range node {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// decodeJSON decodes keeping numbers as json.Number to avoid precision loss.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// patchEntity applies the request patch to the existing entity and returns a
+// new instance. Fields hidden from JSON keep their stored value and, with
+// denormalization groups in context, only writable fields are changed.
+func patchEntity(c *fiber.Ctx, modelType interface{}, existing interface{}) (interface{}, error) {
+	document, err := json.Marshal(existing)
+	if err != nil {
+		return nil, NewInternalError("failed to encode record", err)
+	}
+
+	patched, err := applyPatch(c, document)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := newInstance(modelType)
+	reflect.ValueOf(parsed).Elem().Set(reflect.ValueOf(existing).Elem())
+	serializer.Clear(parsed)
+	if err := json.Unmarshal(patched, parsed); err != nil {
+		return nil, NewBadRequestError("patched document does not match the resource: " + err.Error())
+	}
+
+	instance := newInstance(modelType)
+	reflect.ValueOf(instance).Elem().Set(reflect.ValueOf(existing).Elem())
+	groups, _ := c.Locals("denormalizationGroups").([]string)
+	serializer.Denormalize(instance, parsed, groups)
+
+	return instance, nil
+}
@@ -0,0 +1,309 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type PatchModel struct {
+	ID     uint   `json:"id" gorm:"primarykey"`
+	Name   string `json:"name" groups:"write"`
+	Email  string `json:"email" validate:"omitempty,email"`
+	Age    int    `json:"age" groups:"write"`
+	Secret string `json:"-"`
+}
+
+// setupPatchApp serves PATCH /items/:id through the default provider and
+// processor on a SQLite database seeded with a single record.
+func setupPatchApp(t *testing.T, groups []string) (*fiber.App, *gorm.DB, *PatchModel) {
+	db := testutils.NewTestDB(t, &PatchModel{})
+	record := &PatchModel{Name: "Jane", Email: "jane@example.com", Age: 30, Secret: "s3cret"}
+	require.NoError(t, db.Create(record).Error)
+
+	provider := &DefaultProvider{DB: db}
+	processor := &DefaultProcessor{DB: db}
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			status := fiber.StatusInternalServerError
+			if problemErr, ok := err.(ProblemError); ok {
+				status = problemErr.Problem().Status
+			}
+			return c.Status(status).SendString(err.Error())
+		},
+	})
+	app.Patch("/items/:id", func(c *fiber.Ctx) error {
+		c.Locals("model", &PatchModel{})
+		c.Locals("validationGroups", []string{DefaultValidationGroup, "patch"})
+		c.Locals("denormalizationGroups", groups)
+
+		data, err := provider.Provide(c)
+		if err != nil {
+			return err
+		}
+		result, err := processor.Process(c, data)
+		if err != nil {
+			return err
+		}
+		return c.JSON(result)
+	})
+
+	return app, db, record
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		groups      []string
+		wantStatus  int
+		want        PatchModel
+	}{
+		{
+			name:        "Merge patch updates given members only",
+			contentType: MergePatchContentType,
+			body:        `{"name":"Janet"}`,
+			wantStatus:  fiber.StatusOK,
+			want:        PatchModel{Name: "Janet", Email: "jane@example.com", Age: 30},
+		},
+		{
+			name:        "Plain JSON is a merge patch",
+			contentType: fiber.MIMEApplicationJSONCharsetUTF8,
+			body:        `{"age":31}`,
+			wantStatus:  fiber.StatusOK,
+			want:        PatchModel{Name: "Jane", Email: "jane@example.com", Age: 31},
+		},
+		{
+			name:        "Merge patch null clears a member",
+			contentType: MergePatchContentType,
+			body:        `{"email":null}`,
+			wantStatus:  fiber.StatusOK,
+			want:        PatchModel{Name: "Jane", Age: 30},
+		},
+		{
+			name:        "Merge patch cannot change the ID",
+			contentType: MergePatchContentType,
+			body:        `{"id":99,"name":"Janet"}`,
+			wantStatus:  fiber.StatusOK,
+			want:        PatchModel{Name: "Janet", Email: "jane@example.com", Age: 30},
+		},
+		{
+			name:        "JSON patch applies operations in order",
+			contentType: JSONPatchContentType,
+			body: `[
+				{"op":"test","path":"/name","value":"Jane"},
+				{"op":"replace","path":"/name","value":"Janet"},
+				{"op":"copy","from":"/name","path":"/email"},
+				{"op":"replace","path":"/email","value":"janet@example.com"}
+			]`,
+			wantStatus: fiber.StatusOK,
+			want:       PatchModel{Name: "Janet", Email: "janet@example.com", Age: 30},
+		},
+		{
+			name:        "JSON patch remove clears a member",
+			contentType: JSONPatchContentType,
+			body:        `[{"op":"remove","path":"/age"}]`,
+			wantStatus:  fiber.StatusOK,
+			want:        PatchModel{Name: "Jane", Email: "jane@example.com"},
+		},
+		{
+			name:        "Denormalization groups keep read-only fields",
+			contentType: MergePatchContentType,
+			body:        `{"name":"Janet","email":"janet@example.com"}`,
+			groups:      []string{"write"},
+			wantStatus:  fiber.StatusOK,
+			want:        PatchModel{Name: "Janet", Email: "jane@example.com", Age: 30},
+		},
+		{
+			name:        "Failing test operation is a conflict",
+			contentType: JSONPatchContentType,
+			body:        `[{"op":"test","path":"/name","value":"John"},{"op":"replace","path":"/name","value":"Janet"}]`,
+			wantStatus:  fiber.StatusConflict,
+		},
+		{
+			name:        "Missing path is unprocessable",
+			contentType: JSONPatchContentType,
+			body:        `[{"op":"replace","path":"/nickname","value":"J"}]`,
+			wantStatus:  fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Unknown op is a bad request",
+			contentType: JSONPatchContentType,
+			body:        `[{"op":"merge","path":"/name","value":"J"}]`,
+			wantStatus:  fiber.StatusBadRequest,
+		},
+		{
+			name:        "Malformed merge patch is a bad request",
+			contentType: MergePatchContentType,
+			body:        `["name"]`,
+			wantStatus:  fiber.StatusBadRequest,
+		},
+		{
+			name:        "Patched document must match the model",
+			contentType: MergePatchContentType,
+			body:        `{"age":"thirty"}`,
+			wantStatus:  fiber.StatusBadRequest,
+		},
+		{
+			name:        "Patched record is validated",
+			contentType: MergePatchContentType,
+			body:        `{"email":"not-an-email"}`,
+			wantStatus:  fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Unsupported media type",
+			contentType: fiber.MIMETextPlain,
+			body:        `name=Janet`,
+			wantStatus:  fiber.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _, record := setupPatchApp(t, tt.groups)
+
+			req := httptest.NewRequest("PATCH", "/items/1", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			body, _ := io.ReadAll(resp.Body)
+			require.Equal(t, tt.wantStatus, resp.StatusCode, string(body))
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+
+			var got PatchModel
+			require.NoError(t, json.Unmarshal(body, &got))
+			tt.want.ID = record.ID
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Keeps fields hidden from JSON", func(t *testing.T) {
+		app, db, record := setupPatchApp(t, nil)
+
+		req := httptest.NewRequest("PATCH", "/items/1", bytes.NewBufferString(`{"name":"Janet"}`))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var stored PatchModel
+		require.NoError(t, db.First(&stored, record.ID).Error)
+		assert.Equal(t, "Janet", stored.Name)
+		assert.Equal(t, "s3cret", stored.Secret)
+	})
+
+	t.Run("Record not found", func(t *testing.T) {
+		app, _, _ := setupPatchApp(t, nil)
+
+		req := httptest.NewRequest("PATCH", "/items/42", bytes.NewBufferString(`{"name":"Janet"}`))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     string
+	}{
+		{
+			name:     "Add inserts into arrays",
+			document: `{"tags":["a","c"]}`,
+			patch:    `[{"op":"add","path":"/tags/1","value":"b"}]`,
+			want:     `{"tags":["a","b","c"]}`,
+		},
+		{
+			name:     "Add appends with dash",
+			document: `{"tags":["a"]}`,
+			patch:    `[{"op":"add","path":"/tags/-","value":"b"}]`,
+			want:     `{"tags":["a","b"]}`,
+		},
+		{
+			name:     "Remove deletes array members",
+			document: `{"tags":["a","b","c"]}`,
+			patch:    `[{"op":"remove","path":"/tags/1"}]`,
+			want:     `{"tags":["a","c"]}`,
+		},
+		{
+			name:     "Move relocates values",
+			document: `{"a":{"b":1},"c":{}}`,
+			patch:    `[{"op":"move","from":"/a/b","path":"/c/d"}]`,
+			want:     `{"a":{},"c":{"d":1}}`,
+		},
+		{
+			name:     "Copy does not alias",
+			document: `{"a":{"b":1}}`,
+			patch:    `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:     `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name:     "Pointer tokens are unescaped",
+			document: `{"a/b":1,"m~n":2}`,
+			patch:    `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`,
+			want:     `{"a/b":3}`,
+		},
+		{
+			name:     "Test compares numbers by value",
+			document: `{"n":1,"o":{"x":[1,2]}}`,
+			patch:    `[{"op":"test","path":"/n","value":1},{"op":"test","path":"/o","value":{"x":[1,2]}}]`,
+			want:     `{"n":1,"o":{"x":[1,2]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document interface{}
+			require.NoError(t, decodeJSON([]byte(tt.document), &document))
+			var operations []patchOperation
+			require.NoError(t, json.Unmarshal([]byte(tt.patch), &operations))
+
+			result, err := jsonPatch(document, operations)
+			require.NoError(t, err)
+
+			got, err := json.Marshal(result)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+
+	t.Run("Rejects out of range indexes", func(t *testing.T) {
+		var document interface{}
+		require.NoError(t, decodeJSON([]byte(`{"tags":["a"]}`), &document))
+
+		_, err := jsonPatch(document, []patchOperation{{Op: "remove", Path: ptr("/tags/1")}})
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+}
+
+func TestMergePatch(t *testing.T) {
+	var target, patch interface{}
+	require.NoError(t, decodeJSON([]byte(`{"a":"b","c":{"d":"e","f":"g"}}`), &target))
+	require.NoError(t, decodeJSON([]byte(`{"a":"z","c":{"f":null}}`), &patch))
+
+	got, err := json.Marshal(mergePatch(target, patch))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":"z","c":{"d":"e"}}`, string(got))
+}
+
+func ptr(s string) *string {
+	return &s
+}
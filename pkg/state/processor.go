@@ -1,9 +1,15 @@
 package state
 
 import (
+	"errors"
 	"reflect"
+	"time"
+
+	"github.com/n3crone/gapi-platform/pkg/serializer"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 type DefaultProcessor struct {
@@ -11,12 +17,20 @@ type DefaultProcessor struct {
 }
 
 // Process implements StateProcessor.Process() for GORM-based data manipulation.
+// Writes trigger the pre_validate, pre_write and post_write events, and
+// created or updated records get the values enforced by the query extensions.
 // It handles different HTTP methods:
-// - POST   -> Create new record
-// - PUT    -> Update existing record
-// - DELETE -> Remove record
+// - POST   -> Validate and create new record
+// - PUT    -> Validate and update existing record
+// - PATCH  -> Apply a merge patch or JSON patch, validate and update
+// - DELETE -> Remove record, or mark it as deleted with soft deletion enabled
 // - GET    -> Validates/transforms output
 //
+// With versioning, updates, patches and deletes check the If-Match header
+// and only apply to the version they loaded, see VersionConfig.
+//
+// The restore operation clears the deletion time of a soft deleted record.
+//
 // Parameters:
 //   - c: *fiber.Ctx containing the request context
 //   - data: Current state data from provider
@@ -30,69 +44,243 @@ func (p *DefaultProcessor) Process(c *fiber.Ctx, data interface{}) (interface{},
 		return nil, err
 	}
 
+	if c.Locals("operation") == "restore" {
+		return p.handleRestore(c, data)
+	}
+
 	switch c.Method() {
 	case "POST":
 		return p.handleCreate(c, modelType)
 	case "PUT":
 		return p.handleUpdate(c, modelType, data)
+	case "PATCH":
+		return p.handlePatch(c, modelType, data)
 	case "DELETE":
-		return p.handleDelete(data)
+		return p.handleDelete(c, data)
 	default:
 		return data, nil
 	}
 }
 
 func (p *DefaultProcessor) handleCreate(c *fiber.Ctx, modelType interface{}) (interface{}, error) {
-	newInstance := reflect.New(reflect.ValueOf(modelType).Type().Elem()).Interface()
+	instance, err := parseBody(c, modelType, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := c.BodyParser(newInstance); err != nil {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	if err := p.keepDeletion(c, instance, nil); err != nil {
+		return nil, err
+	}
+	if err := p.enforceExtensions(c, instance); err != nil {
+		return nil, err
+	}
+	if instance, err = prepareWrite(c, instance); err != nil {
+		return nil, err
+	}
+	if err := p.initVersion(c, instance); err != nil {
+		return nil, err
 	}
 
-	result := p.DB.Create(newInstance)
+	result := p.conn(c).Create(instance)
 	if result.Error != nil {
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to create record")
+		return nil, writeError("failed to create record", result.Error)
 	}
 
-	return newInstance, nil
+	return Dispatch(c, EventPostWrite, instance)
 }
 
 func (p *DefaultProcessor) handleUpdate(c *fiber.Ctx, modelType interface{}, existing interface{}) (interface{}, error) {
 	if existing == nil {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "record not found")
+		return nil, NewNotFoundError("record not found")
+	}
+
+	lock, err := p.lock(c, existing)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create new instance for updated data
-	newInstance := reflect.New(reflect.ValueOf(modelType).Type().Elem()).Interface()
+	instance, err := parseBody(c, modelType, existing)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := c.BodyParser(newInstance); err != nil {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	return p.save(c, instance, existing, lock)
+}
+
+func (p *DefaultProcessor) handlePatch(c *fiber.Ctx, modelType interface{}, existing interface{}) (interface{}, error) {
+	if existing == nil {
+		return nil, NewNotFoundError("record not found")
+	}
+
+	lock, err := p.lock(c, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := patchEntity(c, modelType, existing)
+	if err != nil {
+		return nil, err
 	}
 
-	// Copy ID from existing record to ensure we update the correct record
-	existingValue := reflect.ValueOf(existing).Elem()
-	newValue := reflect.ValueOf(newInstance).Elem()
-	if idField := existingValue.FieldByName("ID"); idField.IsValid() {
-		newValue.FieldByName("ID").Set(idField)
+	return p.save(c, instance, existing, lock)
+}
+
+// save validates and stores the updated instance of an existing record.
+// With versioning the update only applies to the locked version.
+func (p *DefaultProcessor) save(c *fiber.Ctx, instance interface{}, existing interface{}, lock *versionLock) (interface{}, error) {
+	if err := p.keepKey(c, instance, existing); err != nil {
+		return nil, err
+	}
+	if err := p.keepDeletion(c, instance, existing); err != nil {
+		return nil, err
+	}
+	if err := p.enforceExtensions(c, instance); err != nil {
+		return nil, err
+	}
+	instance, err := prepareWrite(c, instance)
+	if err != nil {
+		return nil, err
 	}
 
-	result := p.DB.Save(newInstance)
+	var result *gorm.DB
+	if lock == nil {
+		result = p.conn(c).Save(instance)
+	} else {
+		if err := lock.next(c, instance); err != nil {
+			return nil, err
+		}
+		// Unlike Save, Updates does not fall back to an insert when the
+		// version condition matches no row
+		result = p.conn(c).Model(instance).Scopes(lock.scope).Select("*").Updates(instance)
+	}
 	if result.Error != nil {
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to update record")
+		return nil, writeError("failed to update record", result.Error)
+	}
+	if err := lock.check(result); err != nil {
+		return nil, err
+	}
+
+	return Dispatch(c, EventPostWrite, instance)
+}
+
+// keepKey copies the primary key of the existing record onto the instance,
+// so that the body can neither move the record nor write to another one.
+func (p *DefaultProcessor) keepKey(c *fiber.Ctx, instance interface{}, existing interface{}) error {
+	modelSchema, err := parseSchema(p.DB, instance)
+	if err != nil {
+		return err
+	}
+	for _, field := range modelSchema.PrimaryFields {
+		key, _ := field.ValueOf(c.UserContext(), reflect.ValueOf(existing))
+		if err := field.Set(c.UserContext(), reflect.ValueOf(instance), key); err != nil {
+			return NewInternalError("failed to set primary key", err)
+		}
+	}
+	return nil
+}
+
+// prepareWrite runs the pre_validate hooks, validates the instance they
+// return and passes it through the pre_write hooks.
+func prepareWrite(c *fiber.Ctx, instance interface{}) (interface{}, error) {
+	instance, err := Dispatch(c, EventPreValidate, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateInput(c, instance); err != nil {
+		return nil, err
 	}
 
-	return newInstance, nil
+	return Dispatch(c, EventPreWrite, instance)
 }
 
-func (p *DefaultProcessor) handleDelete(data interface{}) (interface{}, error) {
+// parseBody decodes the request body into a new instance of the model.
+// With denormalization groups in context only writable fields are taken from
+// the body; the others keep their value from base, or stay zero without one.
+func parseBody(c *fiber.Ctx, modelType interface{}, base interface{}) (interface{}, error) {
+	instance := newInstance(modelType)
+
+	groups, _ := c.Locals("denormalizationGroups").([]string)
+	if len(groups) == 0 {
+		if err := c.BodyParser(instance); err != nil {
+			return nil, NewBadRequestError("invalid request body")
+		}
+		return instance, nil
+	}
+
+	parsed := newInstance(modelType)
+	if err := c.BodyParser(parsed); err != nil {
+		return nil, NewBadRequestError("invalid request body")
+	}
+
+	if base != nil && reflect.TypeOf(base) == reflect.TypeOf(instance) {
+		reflect.ValueOf(instance).Elem().Set(reflect.ValueOf(base).Elem())
+	}
+	serializer.Denormalize(instance, parsed, groups)
+
+	return instance, nil
+}
+
+func (p *DefaultProcessor) handleDelete(c *fiber.Ctx, data interface{}) (interface{}, error) {
 	if data == nil {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "no data to delete")
+		return nil, NewNotFoundError("no data to delete")
 	}
 
-	result := p.DB.Delete(data)
-	if result.Error != nil {
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to delete record")
+	lock, err := p.lock(c, data)
+	if err != nil {
+		return nil, err
 	}
 
+	data, err = Dispatch(c, EventPreWrite, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if softDeleteConfig(c).Enabled {
+		if err := p.markDeleted(c, data, time.Now(), lock); err != nil {
+			return nil, err
+		}
+	} else {
+		conn := p.conn(c)
+		if lock != nil {
+			conn = conn.Scopes(lock.scope)
+		}
+		result := conn.Delete(data)
+		if result.Error != nil {
+			return nil, writeError("failed to delete record", result.Error)
+		}
+		if err := lock.check(result); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := Dispatch(c, EventPostWrite, data); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
+
+// conn returns the connection for the request's writes, bound to the
+// request's user context so that they join the request trace. Everything it
+// runs, reads included, goes to the primary.
+func (p *DefaultProcessor) conn(c *fiber.Ctx) GormDB {
+	if db, ok := p.DB.(*gorm.DB); ok {
+		return db.WithContext(c.UserContext()).Clauses(dbresolver.Write)
+	}
+	return p.DB
+}
+
+// writeError maps database write failures to typed errors. Constraint
+// violations become conflicts; anything else is an internal error.
+// Requires gorm.Config.TranslateError to recognize dialect-specific errors.
+func writeError(detail string, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return NewConflictError("a record with the same unique value already exists", err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return NewConflictError("the record references or is referenced by other records", err)
+	default:
+		return NewInternalError(detail, err)
+	}
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"bytes"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -30,7 +31,8 @@ func setupTestProcessor(_ *testing.T) (*DefaultProcessor, *testutils.MockDB, *fi
 	processor := &DefaultProcessor{DB: mockDB}
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			if ferr, ok := err.(*fiber.Error); ok {
+			var ferr *fiber.Error
+			if errors.As(err, &ferr) {
 				return c.Status(ferr.Code).JSON(fiber.Map{
 					"error": ferr.Message,
 				})
@@ -95,6 +97,46 @@ func TestProcess(t *testing.T) {
 		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 	})
 
+	t.Run("Keeps a primary key other than ID", func(t *testing.T) {
+		type Country struct {
+			Code string `json:"code" gorm:"primaryKey"`
+			Name string `json:"name"`
+		}
+		db := testutils.NewTestDB(t, &Country{})
+		require.NoError(t, db.Create(&Country{Code: "fr", Name: "France"}).Error)
+		provider := &DefaultProvider{DB: db}
+		processor := &DefaultProcessor{DB: db}
+
+		app := fiber.New()
+		handler := func(c *fiber.Ctx) error {
+			c.Locals("model", &Country{})
+			existing, err := provider.Provide(c)
+			if err != nil {
+				return err
+			}
+			data, err := processor.Process(c, existing)
+			if err != nil {
+				return err
+			}
+			return c.JSON(data)
+		}
+		app.Put("/:id", handler)
+		app.Patch("/:id", handler)
+
+		for _, request := range []struct{ method, payload string }{
+			{"PUT", `{"code":"de","name":"Germany"}`},
+			{"PATCH", `{"code":"it","name":"Italy"}`},
+		} {
+			resp := testutils.Request(t, app, request.method, "/fr", request.payload)
+			require.Equal(t, fiber.StatusOK, resp.Status, resp.Body)
+			assert.Contains(t, resp.Body, `"code":"fr"`, request.method)
+		}
+
+		var countries []Country
+		require.NoError(t, db.Find(&countries).Error)
+		assert.Equal(t, []Country{{Code: "fr", Name: "Italy"}}, countries)
+	})
+
 	t.Run("Delete operation successfully", func(t *testing.T) {
 		processor, _, app := setupTestProcessor(t)
 
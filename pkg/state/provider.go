@@ -1,10 +1,14 @@
 package state
 
 import (
-	"reflect"
+	"errors"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
 )
 
 // DefaultProvider implements the StateProvider interface for GORM database operations.
@@ -12,6 +16,7 @@ import (
 // - Handling both single and collection queries
 // - Supporting dynamic model types
 // - Managing database connections via GORM
+// - Routing get_item and get_list reads to replicas when the database has any
 type DefaultProvider struct {
 	DB GormDB
 }
@@ -22,12 +27,14 @@ type GormDB interface {
 	Create(value interface{}) *gorm.DB
 	Save(value interface{}) *gorm.DB
 	Delete(value interface{}, conds ...interface{}) *gorm.DB
+	Model(value interface{}) *gorm.DB
+	Scopes(funcs ...func(*gorm.DB) *gorm.DB) *gorm.DB
 }
 
 // Provide implements StateProvider.Provide() for GORM-based data retrieval.
 // It determines the appropriate query type based on URL parameters:
 // - GET /{resource}/:id -> Single item lookup
-// - GET /{resource}     -> Collection lookup
+// - GET /{resource}     -> Paginated collection lookup
 //
 // Parameters:
 //   - c: *fiber.Ctx containing the request context and model information
@@ -42,34 +49,183 @@ func (p *DefaultProvider) Provide(c *fiber.Ctx) (interface{}, error) {
 	}
 
 	if id := c.Params("id"); id != "" {
-		return p.findById(id, modelType)
+		return p.findById(c, id, modelType)
 	}
 
-	return p.findAll(modelType)
+	return p.findAll(c, modelType)
 }
 
-// findById retrieves a single record by ID
-func (p *DefaultProvider) findById(id string, modelType interface{}) (interface{}, error) {
-	result := p.DB.First(modelType, id)
+// findById retrieves a single record by ID into a newly allocated instance.
+// The ID must be valid for the type of the primary key. Records outside the
+// scope of the query extensions are not found, nor are soft deleted records
+// except by the restore operation. Versioned records read by get_item carry
+// their version in the ETag header.
+func (p *DefaultProvider) findById(c *fiber.Ctx, id string, modelType interface{}) (interface{}, error) {
+	instance := newInstance(modelType)
+
+	modelSchema, err := parseSchema(p.DB, modelType)
+	if err != nil {
+		return nil, err
+	}
+	if modelSchema.PrioritizedPrimaryField == nil {
+		return nil, NewInternalError("model has no primary key", nil)
+	}
+	key, ok := parseFieldValue(modelSchema.PrioritizedPrimaryField, id)
+	if !ok {
+		return nil, NewBadRequestError("invalid id " + strconv.Quote(id))
+	}
+
+	deleted := DeletedExclude
+	if c.Locals("operation") == "restore" {
+		deleted = DeletedInclude
+	}
+	scope, err := p.scope(c, modelType, deleted)
+	if err != nil {
+		return nil, err
+	}
+	conn := p.conn(c)
+	if scope != nil {
+		conn = conn.Scopes(scope)
+	}
+
+	result := conn.First(instance, clause.Eq{Column: clause.PrimaryColumn, Value: key})
 	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			return nil, fiber.NewError(fiber.StatusNotFound, "record not found")
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, NewNotFoundError("record not found")
 		}
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "database error")
+		return nil, NewInternalError("database error", result.Error)
 	}
 
-	return modelType, nil
+	if c.Locals("operation") == "get_item" {
+		if err := setETag(c, p.DB, instance); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
 }
 
-// findAll retrieves all records of the given model type
-func (p *DefaultProvider) findAll(modelType interface{}) (interface{}, error) {
-	modelValue := reflect.ValueOf(modelType)
-	results := reflect.New(reflect.SliceOf(modelValue.Type().Elem())).Interface()
+// findAll retrieves records of the given model type into a newly allocated slice.
+// The query extensions, the requested deletion state and the declared filters
+// present in the query string restrict the results and the requested or
+// default order sorts them.
+// Unless pagination is disabled, only the requested page is loaded and the
+// results are wrapped in a Collection or CursorCollection envelope.
+func (p *DefaultProvider) findAll(c *fiber.Ctx, modelType interface{}) (interface{}, error) {
+	results := newSlice(modelType)
+
+	filter, err := p.filterScope(c, modelType)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := RequestedDeleted(c)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := p.scope(c, modelType, deleted)
+	if err != nil {
+		return nil, err
+	}
+	if scope != nil {
+		filter = chainScopes(scope, filter)
+	}
+
+	config := paginationConfig(c)
+	if config.Mode == PaginationModeCursor && !config.Disabled {
+		return p.findAllByCursor(c, modelType, config, filter)
+	}
+
+	order, err := p.orderScope(c, modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Disabled {
+		result := p.conn(c).Scopes(filter, order).Find(results)
+		if result.Error != nil {
+			return nil, NewInternalError("failed to fetch records", result.Error)
+		}
+		return results, nil
+	}
+
+	page, err := parsePageRequest(c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := p.conn(c).Model(modelType).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, NewInternalError("failed to count records", err)
+	}
 
-	result := p.DB.Find(results)
+	result := p.conn(c).Scopes(filter, order, page.scope).Find(results)
 	if result.Error != nil {
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch records")
+		return nil, NewInternalError("failed to fetch records", result.Error)
 	}
 
-	return results, nil
+	return newCollection(c, results, total, page), nil
+}
+
+// scope combines the soft delete and query extension scopes of the model,
+// or returns nil when none applies.
+func (p *DefaultProvider) scope(c *fiber.Ctx, modelType interface{}, deleted Deleted) (func(*gorm.DB) *gorm.DB, error) {
+	softDelete, err := p.softDeleteScope(c, modelType, deleted)
+	if err != nil {
+		return nil, err
+	}
+	extension, err := p.extensionScope(c, modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case softDelete == nil:
+		return extension, nil
+	case extension == nil:
+		return softDelete, nil
+	default:
+		return chainScopes(softDelete, extension), nil
+	}
+}
+
+// parseSchema returns the GORM schema of the model type.
+func parseSchema(db GormDB, modelType interface{}) (*schema.Schema, error) {
+	stmt := db.Model(modelType).Statement
+	if err := stmt.Parse(modelType); err != nil {
+		return nil, NewInternalError("failed to parse model schema", err)
+	}
+	return stmt.Schema, nil
+}
+
+// ReadFromPrimary routes the remaining reads of the request to the primary
+// database instead of a replica. Use it when a client must see its own
+// writes before they reach the replicas, e.g. from a middleware:
+//
+//	app.Fiber.Use(func(c *fiber.Ctx) error {
+//		if c.Cookies("recent_write") != "" {
+//			state.ReadFromPrimary(c)
+//		}
+//		return c.Next()
+//	})
+func ReadFromPrimary(c *fiber.Ctx) {
+	c.Locals("readFromPrimary", true)
+}
+
+// conn returns the connection for the request's queries. Only the read
+// operations get_item and get_list may use a replica; the loads preceding an
+// update, patch or delete must see the latest state and use the primary.
+// Databases without replicas ignore the routing clauses. Queries run in the
+// request's user context, so they join the request trace.
+func (p *DefaultProvider) conn(c *fiber.Ctx) GormDB {
+	db, ok := p.DB.(*gorm.DB)
+	if !ok {
+		return p.DB
+	}
+	db = db.WithContext(c.UserContext())
+
+	operation, _ := c.Locals("operation").(string)
+	primary, _ := c.Locals("readFromPrimary").(bool)
+	if (operation == "get_item" || operation == "get_list") && !primary {
+		return db.Clauses(dbresolver.Read)
+	}
+	return db.Clauses(dbresolver.Write)
 }
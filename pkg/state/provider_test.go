@@ -1,7 +1,9 @@
 package state
 
 import (
+	"fmt"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/n3crone/gapi-platform/testutils"
@@ -9,7 +11,9 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 func setupTestProvider(_ *testing.T) (*DefaultProvider, *testutils.MockDB, *fiber.App) {
@@ -26,6 +30,17 @@ func setupTestProvider(_ *testing.T) (*DefaultProvider, *testutils.MockDB, *fibe
 	return provider, mockDB, app
 }
 
+// setupTestListProvider creates a provider backed by a test database
+// seeded with count sequentially named records.
+func setupTestListProvider(t *testing.T, count int) (*DefaultProvider, *fiber.App) {
+	db := testutils.NewTestDB(t, &TestModel{})
+	for i := 1; i <= count; i++ {
+		require.NoError(t, db.Create(&TestModel{Name: fmt.Sprintf("Test %d", i)}).Error)
+	}
+
+	return &DefaultProvider{DB: db}, fiber.New()
+}
+
 func TestProvide(t *testing.T) {
 	t.Run("Get single record by ID successfully", func(t *testing.T) {
 		provider, _, app := setupTestProvider(t)
@@ -50,16 +65,19 @@ func TestProvide(t *testing.T) {
 	})
 
 	t.Run("Get all records successfully", func(t *testing.T) {
-		provider, _, app := setupTestProvider(t)
+		provider, app := setupTestListProvider(t, 2)
 
 		app.Get("/", func(c *fiber.Ctx) error {
 			c.Locals("model", &TestModel{})
 			data, err := provider.Provide(c)
 			require.NoError(t, err)
 
-			models, ok := data.(*[]TestModel)
+			collection, ok := data.(*Collection)
+			require.True(t, ok)
+			models, ok := collection.Items.(*[]TestModel)
 			require.True(t, ok)
 			assert.Len(t, *models, 2)
+			assert.Equal(t, int64(2), collection.TotalItems)
 
 			return nil
 		})
@@ -79,9 +97,9 @@ func TestProvide(t *testing.T) {
 			_, err := provider.Provide(c)
 			assert.Error(t, err)
 
-			fiberErr, ok := err.(*fiber.Error)
-			require.True(t, ok)
-			assert.Equal(t, fiber.StatusNotFound, fiberErr.Code)
+			var notFound *NotFoundError
+			require.ErrorAs(t, err, &notFound)
+			assert.Equal(t, fiber.StatusNotFound, notFound.StatusCode())
 
 			return err
 		})
@@ -92,6 +110,52 @@ func TestProvide(t *testing.T) {
 		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
 	})
 
+	t.Run("Invalid ID", func(t *testing.T) {
+		provider, _, app := setupTestProvider(t)
+
+		app.Get("/:id", func(c *fiber.Ctx) error {
+			c.Locals("model", &TestModel{})
+			_, err := provider.Provide(c)
+
+			var badRequest *BadRequestError
+			require.ErrorAs(t, err, &badRequest)
+			return err
+		})
+
+		for _, target := range []string{"/abc", "/-1", "/1.5", "/99999999999999999999"} {
+			resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, target)
+		}
+	})
+
+	t.Run("Finds records by string key", func(t *testing.T) {
+		type Country struct {
+			Code string `gorm:"primaryKey"`
+			Name string
+		}
+		db := testutils.NewTestDB(t, &Country{})
+		require.NoError(t, db.Create(&Country{Code: "fr", Name: "France"}).Error)
+		provider := &DefaultProvider{DB: db}
+
+		app := fiber.New()
+		app.Get("/:id", func(c *fiber.Ctx) error {
+			c.Locals("model", &Country{})
+			data, err := provider.Provide(c)
+			if err != nil {
+				return err
+			}
+			return c.SendString(data.(*Country).Name)
+		})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/fr", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		resp, err = app.Test(httptest.NewRequest("GET", "/abc", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+
 	t.Run("Database error", func(t *testing.T) {
 		provider, mockDB, app := setupTestProvider(t)
 		mockDB.FindAllError = gorm.ErrInvalidTransaction
@@ -101,9 +165,9 @@ func TestProvide(t *testing.T) {
 			_, err := provider.Provide(c)
 			assert.Error(t, err)
 
-			fiberErr, ok := err.(*fiber.Error)
-			require.True(t, ok)
-			assert.Equal(t, fiber.StatusInternalServerError, fiberErr.Code)
+			var internal *InternalError
+			require.ErrorAs(t, err, &internal)
+			assert.Equal(t, fiber.StatusInternalServerError, internal.StatusCode())
 
 			return err
 		})
@@ -122,9 +186,9 @@ func TestProvide(t *testing.T) {
 			_, err := provider.Provide(c)
 			assert.Error(t, err)
 
-			fiberErr, ok := err.(*fiber.Error)
-			require.True(t, ok)
-			assert.Equal(t, fiber.StatusBadRequest, fiberErr.Code)
+			var badRequest *BadRequestError
+			require.ErrorAs(t, err, &badRequest)
+			assert.Equal(t, fiber.StatusBadRequest, badRequest.StatusCode())
 
 			return err
 		})
@@ -135,3 +199,133 @@ func TestProvide(t *testing.T) {
 		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
 	})
 }
+
+// setupReplicaProvider creates a provider whose database routes reads to a
+// replica. Both databases hold record 1 under a different name, revealing
+// which one served a query.
+func setupReplicaProvider(t *testing.T) (*DefaultProvider, *gorm.DB) {
+	primary := testutils.NewTestDB(t, &TestModel{})
+	replica := testutils.NewTestDB(t, &TestModel{})
+	require.NoError(t, primary.Create(&TestModel{ID: 1, Name: "Primary"}).Error)
+	require.NoError(t, replica.Create(&TestModel{ID: 1, Name: "Replica"}).Error)
+
+	conn, err := replica.DB()
+	require.NoError(t, err)
+	require.NoError(t, primary.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{sqlite.Dialector{Conn: conn}},
+	})))
+
+	return &DefaultProvider{DB: primary}, primary
+}
+
+func TestReplicaRouting(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		operation   string
+		fromPrimary bool
+		expected    string
+	}{
+		{name: "Item reads use the replica", method: "GET", path: "/1", operation: "get_item", expected: "Replica"},
+		{name: "Collection reads use the replica", method: "GET", path: "/", operation: "get_list", expected: "Replica"},
+		{name: "Forced reads use the primary", method: "GET", path: "/1", operation: "get_item", fromPrimary: true, expected: "Primary"},
+		{name: "Forced collection reads use the primary", method: "GET", path: "/", operation: "get_list", fromPrimary: true, expected: "Primary"},
+		{name: "Loads before an update use the primary", method: "PUT", path: "/1", operation: "update", expected: "Primary"},
+		{name: "Loads before a patch use the primary", method: "PATCH", path: "/1", operation: "patch", expected: "Primary"},
+		{name: "Loads before a delete use the primary", method: "DELETE", path: "/1", operation: "delete", expected: "Primary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, _ := setupReplicaProvider(t)
+			app := fiber.New()
+
+			var name string
+			app.Add(tt.method, "/:id?", func(c *fiber.Ctx) error {
+				c.Locals("model", &TestModel{})
+				c.Locals("operation", tt.operation)
+				if tt.fromPrimary {
+					ReadFromPrimary(c)
+				}
+
+				data, err := provider.Provide(c)
+				require.NoError(t, err)
+
+				switch data := data.(type) {
+				case *TestModel:
+					name = data.Name
+				case *Collection:
+					models := *data.Items.(*[]TestModel)
+					require.Len(t, models, 1)
+					assert.Equal(t, int64(1), data.TotalItems)
+					name = models[0].Name
+				}
+				return nil
+			})
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.Equal(t, tt.expected, name)
+		})
+	}
+
+	t.Run("Writes use the primary", func(t *testing.T) {
+		provider, primary := setupReplicaProvider(t)
+		processor := &DefaultProcessor{DB: primary}
+		app := fiber.New()
+
+		app.Post("/", func(c *fiber.Ctx) error {
+			c.Locals("model", &TestModel{})
+			c.Locals("operation", "create")
+			_, err := processor.Process(c, nil)
+			return err
+		})
+		app.Get("/:id", func(c *fiber.Ctx) error {
+			c.Locals("model", &TestModel{})
+			c.Locals("operation", "get_item")
+			if c.Query("primary") != "" {
+				ReadFromPrimary(c)
+			}
+			data, err := provider.Provide(c)
+			if err != nil {
+				return err
+			}
+			return c.JSON(data)
+		})
+
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"Created"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		// The write has not reached the replica, only the primary sees it
+		resp, err = app.Test(httptest.NewRequest("GET", "/2", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		resp, err = app.Test(httptest.NewRequest("GET", "/2?primary=1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Processor queries use the primary", func(t *testing.T) {
+		_, primary := setupReplicaProvider(t)
+		processor := &DefaultProcessor{DB: primary}
+		app := fiber.New()
+
+		var name string
+		app.Get("/", func(c *fiber.Ctx) error {
+			var model TestModel
+			require.NoError(t, processor.conn(c).First(&model, 1).Error)
+			name = model.Name
+			return nil
+		})
+
+		_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "Primary", name)
+	})
+}
@@ -0,0 +1,171 @@
+package state
+
+import (
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Soft delete defaults applied when a SoftDeleteConfig leaves them unset.
+const (
+	DefaultSoftDeleteColumn = "deleted_at"
+	DefaultDeletedParam     = "deleted"
+)
+
+// SoftDeleteConfig enables soft deletion: the default processor marks
+// deleted records with their deletion time instead of removing them and the
+// default provider hides them. The column is a nullable time, e.g. a
+// *time.Time, sql.NullTime or gorm.DeletedAt field of the model.
+type SoftDeleteConfig struct {
+	Enabled bool   // Mark deleted records instead of removing them
+	Column  string // Column holding the deletion time, defaults to DefaultSoftDeleteColumn
+	Param   string // Query parameter listing deleted records on get_list, defaults to DefaultDeletedParam
+}
+
+// Deleted selects the records of a collection by deletion state.
+type Deleted string
+
+// Values of the deleted query parameter
+const (
+	DeletedExclude Deleted = "exclude" // Live records only (default)
+	DeletedInclude Deleted = "include" // Live and deleted records
+	DeletedOnly    Deleted = "only"    // Deleted records only
+)
+
+// softDeleteConfig returns the soft delete settings from context, with
+// defaults applied.
+func softDeleteConfig(c *fiber.Ctx) SoftDeleteConfig {
+	config, _ := c.Locals("softDelete").(SoftDeleteConfig)
+	if config.Column == "" {
+		config.Column = DefaultSoftDeleteColumn
+	}
+	if config.Param == "" {
+		config.Param = DefaultDeletedParam
+	}
+	return config
+}
+
+// RequestedDeleted returns the deletion state of the records the request
+// lists, from the ?deleted=exclude|include|only query parameter. It returns
+// DeletedExclude when soft deletion is disabled or the parameter is absent.
+func RequestedDeleted(c *fiber.Ctx) (Deleted, error) {
+	config := softDeleteConfig(c)
+	if !config.Enabled {
+		return DeletedExclude, nil
+	}
+
+	switch value := Deleted(c.Query(config.Param)); value {
+	case "":
+		return DeletedExclude, nil
+	case DeletedExclude, DeletedInclude, DeletedOnly:
+		return value, nil
+	default:
+		return "", NewBadRequestError("invalid " + config.Param + " parameter: expected exclude, include or only")
+	}
+}
+
+// softDeleteScope restricts the queries to the records in the deletion
+// state, or returns nil when soft deletion is disabled. GORM's own
+// gorm.DeletedAt condition is lifted so that deleted records can be read.
+func (p *DefaultProvider) softDeleteScope(c *fiber.Ctx, modelType interface{}, deleted Deleted) (func(*gorm.DB) *gorm.DB, error) {
+	config := softDeleteConfig(c)
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	field, err := softDeleteField(p.DB, modelType, config)
+	if err != nil {
+		return nil, err
+	}
+	column := fieldColumn(field)
+
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Unscoped()
+		switch deleted {
+		case DeletedInclude:
+			return db
+		case DeletedOnly:
+			return db.Where(clause.Neq{Column: column, Value: nil})
+		default:
+			return db.Where(clause.Eq{Column: column, Value: nil})
+		}
+	}, nil
+}
+
+// softDeleteField returns the field holding the deletion time.
+func softDeleteField(db GormDB, modelType interface{}, config SoftDeleteConfig) (*schema.Field, error) {
+	modelSchema, err := parseSchema(db, modelType)
+	if err != nil {
+		return nil, err
+	}
+	field := modelSchema.LookUpField(config.Column)
+	if field == nil || field.DBName == "" {
+		return nil, NewInternalError("invalid soft delete column "+config.Column, nil)
+	}
+	return field, nil
+}
+
+// keepDeletion sets the deletion time of the instance to that of the
+// existing record, or clears it without one, so that writes neither delete
+// nor restore records.
+func (p *DefaultProcessor) keepDeletion(c *fiber.Ctx, instance interface{}, existing interface{}) error {
+	config := softDeleteConfig(c)
+	if !config.Enabled {
+		return nil
+	}
+
+	field, err := softDeleteField(p.DB, instance, config)
+	if err != nil {
+		return err
+	}
+	var deletedAt interface{}
+	if existing != nil {
+		deletedAt, _ = field.ValueOf(c.UserContext(), reflect.ValueOf(existing))
+	}
+	if err := field.Set(c.UserContext(), reflect.ValueOf(instance), deletedAt); err != nil {
+		return NewInternalError("failed to set deletion time", err)
+	}
+	return nil
+}
+
+// markDeleted sets the deletion time of a record, or clears it when
+// deletedAt is nil, both in the database and on the instance. With a lock
+// the record is only updated in the locked version.
+func (p *DefaultProcessor) markDeleted(c *fiber.Ctx, instance interface{}, deletedAt interface{}, lock *versionLock) error {
+	field, err := softDeleteField(p.DB, instance, softDeleteConfig(c))
+	if err != nil {
+		return err
+	}
+
+	result := p.conn(c).Model(instance).Unscoped().Scopes(lock.scope).UpdateColumn(field.DBName, deletedAt)
+	if result.Error != nil {
+		return writeError("failed to update deletion time", result.Error)
+	}
+	if err := lock.check(result); err != nil {
+		return err
+	}
+	if err := field.Set(c.UserContext(), reflect.ValueOf(instance), deletedAt); err != nil {
+		return NewInternalError("failed to set deletion time", err)
+	}
+	return nil
+}
+
+// handleRestore brings a deleted record back, around the pre_write and
+// post_write hooks. Restoring a live record leaves it unchanged.
+func (p *DefaultProcessor) handleRestore(c *fiber.Ctx, data interface{}) (interface{}, error) {
+	if data == nil {
+		return nil, NewNotFoundError("record not found")
+	}
+
+	data, err := Dispatch(c, EventPreWrite, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.markDeleted(c, data, nil, nil); err != nil {
+		return nil, err
+	}
+	return Dispatch(c, EventPostWrite, data)
+}
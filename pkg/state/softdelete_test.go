@@ -0,0 +1,93 @@
+package state
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TrashedModel struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	Name      string     `json:"name"`
+	DeletedAt *time.Time `json:"deletedAt"`
+}
+
+// setupTrashApp lists 5 records, the even ones being deleted, through the
+// provider in cursor mode.
+func setupTrashApp(t *testing.T, config SoftDeleteConfig) *fiber.App {
+	db := testutils.NewTestDB(t, &TrashedModel{})
+	deletedAt := time.Now()
+	for i := 1; i <= 5; i++ {
+		record := &TrashedModel{}
+		if i%2 == 0 {
+			record.DeletedAt = &deletedAt
+		}
+		require.NoError(t, db.Create(record).Error)
+	}
+
+	provider := &DefaultProvider{DB: db}
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		c.Locals("model", &TrashedModel{})
+		c.Locals("pagination", PaginationConfig{Mode: PaginationModeCursor, DefaultPageSize: 2, CursorSecret: []byte("test-secret")})
+		c.Locals("softDelete", config)
+		data, err := provider.Provide(c)
+		if err != nil {
+			return err
+		}
+		return c.JSON(data)
+	})
+	return app
+}
+
+func TestSoftDeleteScope(t *testing.T) {
+	t.Run("Pages through the requested records", func(t *testing.T) {
+		app := setupTrashApp(t, SoftDeleteConfig{Enabled: true})
+
+		tests := map[string][]uint{
+			"/items":                 {1, 3, 5},
+			"/items?deleted=exclude": {1, 3, 5},
+			"/items?deleted=include": {1, 2, 3, 4, 5},
+			"/items?deleted=only":    {2, 4},
+		}
+		for target, want := range tests {
+			var ids []uint
+			for next := target; next != ""; {
+				status, page := fetchCursorPage(t, app, next)
+				require.Equal(t, fiber.StatusOK, status, target)
+				ids = append(ids, itemIDs(page.Items)...)
+				next = page.Next
+			}
+			assert.Equal(t, want, ids, target)
+		}
+	})
+
+	t.Run("Rejects unknown deletion states", func(t *testing.T) {
+		app := setupTrashApp(t, SoftDeleteConfig{Enabled: true})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/items?deleted=all", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Rejects unknown columns", func(t *testing.T) {
+		app := setupTrashApp(t, SoftDeleteConfig{Enabled: true, Column: "removed_at"})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/items", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	})
+
+	t.Run("Lists every record when disabled", func(t *testing.T) {
+		app := setupTrashApp(t, SoftDeleteConfig{})
+
+		_, page := fetchCursorPage(t, app, "/items?deleted=only&itemsPerPage=10")
+		assert.Equal(t, []uint{1, 2, 3, 4, 5}, itemIDs(page.Items))
+	})
+}
@@ -20,12 +20,12 @@ import (
 func validateModel(c *fiber.Ctx) (interface{}, error) {
 	modelType := c.Locals("model")
 	if modelType == nil {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "model not found in context")
+		return nil, NewBadRequestError("model not found in context")
 	}
 
 	modelValue := reflect.ValueOf(modelType)
 	if modelValue.Kind() != reflect.Ptr || modelValue.Elem().Kind() != reflect.Struct {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid model type")
+		return nil, NewBadRequestError("invalid model type")
 	}
 
 	return modelType, nil
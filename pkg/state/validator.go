@@ -0,0 +1,140 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultValidationGroup is the group of constraints declared in the
+// `validate` struct tag. Constraints of any other group are declared in a
+// `validate_<group>` tag, e.g. `validate_create:"required"`.
+const DefaultValidationGroup = "default"
+
+// Violation describes a single failed constraint of the request body.
+type Violation struct {
+	PropertyPath string `json:"propertyPath"` // JSON path of the invalid property, e.g. address.street
+	Message      string `json:"message"`      // Human readable explanation
+	Constraint   string `json:"constraint"`   // Name of the failed rule, e.g. required
+}
+
+// validators caches one validator per group since each group reads its
+// constraints from a different struct tag.
+var validators sync.Map
+
+// validatorFor returns the validator reading the struct tag of the given group.
+func validatorFor(group string) *validator.Validate {
+	if v, ok := validators.Load(group); ok {
+		return v.(*validator.Validate)
+	}
+
+	tag := "validate"
+	if group != DefaultValidationGroup {
+		tag += "_" + group
+	}
+
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName(tag)
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	actual, _ := validators.LoadOrStore(group, v)
+	return actual.(*validator.Validate)
+}
+
+// validationGroups returns the groups stored in the context for the current
+// operation, or only the default group when none are configured.
+func validationGroups(c *fiber.Ctx) []string {
+	if groups, ok := c.Locals("validationGroups").([]string); ok && len(groups) > 0 {
+		return groups
+	}
+	return []string{DefaultValidationGroup}
+}
+
+// validateInput checks the instance against the constraints of every active
+// validation group and reports all violations at once.
+func validateInput(c *fiber.Ctx, instance interface{}) error {
+	var violations []Violation
+
+	for _, group := range validationGroups(c) {
+		err := validatorFor(group).Struct(instance)
+		if err == nil {
+			continue
+		}
+
+		var fieldErrors validator.ValidationErrors
+		if !errors.As(err, &fieldErrors) {
+			return NewInternalError("failed to validate request body", err)
+		}
+		for _, fieldError := range fieldErrors {
+			violations = append(violations, newViolation(fieldError))
+		}
+	}
+
+	if len(violations) > 0 {
+		return NewValidationError("request body contains invalid values", violations...)
+	}
+	return nil
+}
+
+// newViolation converts a validator error into a Violation keyed by the
+// JSON path of the property, without the root struct name.
+func newViolation(fieldError validator.FieldError) Violation {
+	path := fieldError.Namespace()
+	if _, rest, found := strings.Cut(path, "."); found {
+		path = rest
+	}
+
+	return Violation{
+		PropertyPath: path,
+		Message:      violationMessage(fieldError),
+		Constraint:   fieldError.Tag(),
+	}
+}
+
+// violationMessage describes the common constraints in plain words.
+func violationMessage(fieldError validator.FieldError) string {
+	param := fieldError.Param()
+	sized := fieldError.Kind() == reflect.String || fieldError.Kind() == reflect.Slice || fieldError.Kind() == reflect.Map
+
+	switch fieldError.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "This value is required."
+	case "email":
+		return "This value is not a valid email address."
+	case "url", "http_url":
+		return "This value is not a valid URL."
+	case "uuid", "uuid4":
+		return "This value is not a valid UUID."
+	case "oneof":
+		return "This value must be one of: " + strings.Join(strings.Fields(param), ", ") + "."
+	case "min", "gte":
+		if sized {
+			return fmt.Sprintf("This value must contain at least %s elements or characters.", param)
+		}
+		return fmt.Sprintf("This value must be greater than or equal to %s.", param)
+	case "max", "lte":
+		if sized {
+			return fmt.Sprintf("This value must contain at most %s elements or characters.", param)
+		}
+		return fmt.Sprintf("This value must be less than or equal to %s.", param)
+	case "len":
+		return fmt.Sprintf("This value must contain exactly %s elements or characters.", param)
+	case "gt":
+		return fmt.Sprintf("This value must be greater than %s.", param)
+	case "lt":
+		return fmt.Sprintf("This value must be less than %s.", param)
+	default:
+		return fmt.Sprintf("This value failed the %q constraint.", fieldError.Tag())
+	}
+}
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type ValidatedAddress struct {
+	Street string `json:"street" validate:"max=5"`
+}
+
+type ValidatedModel struct {
+	ID      uint             `json:"id" gorm:"primarykey"`
+	Name    string           `json:"name" validate:"omitempty,min=2,max=10" validate_create:"required"`
+	Email   string           `json:"email" validate:"required,email"`
+	Role    string           `json:"role" validate:"omitempty,oneof=admin user"`
+	Age     int              `json:"age" validate:"gte=0,lte=150"`
+	Address ValidatedAddress `json:"address" gorm:"embedded"`
+}
+
+// processValidated runs the processor for the given method and body with
+// the validation groups of the named operation and returns its error.
+func processValidated(t *testing.T, method string, operation string, payload string) error {
+	// Writes fail loudly so that a missing validation shows up as a 500
+	mockDB := &testutils.MockDB{CreateError: gorm.ErrInvalidData, UpdateError: gorm.ErrInvalidData}
+	processor := &DefaultProcessor{DB: mockDB}
+
+	var processErr error
+	app := fiber.New()
+	app.Add(method, "/users/:id?", func(c *fiber.Ctx) error {
+		c.Locals("model", &ValidatedModel{})
+		c.Locals("validationGroups", []string{DefaultValidationGroup, operation})
+		_, processErr = processor.Process(c, &ValidatedModel{ID: 1})
+		return processErr
+	})
+
+	req := httptest.NewRequest(method, "/users/1", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	return processErr
+}
+
+func TestInputValidation(t *testing.T) {
+	t.Run("Reports every violation by JSON property path", func(t *testing.T) {
+		err := processValidated(t, "POST", "create",
+			`{"email":"not-an-email","role":"root","age":200,"address":{"street":"Long Street"}}`)
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, fiber.StatusUnprocessableEntity, validationErr.StatusCode())
+		assert.ElementsMatch(t, []Violation{
+			{PropertyPath: "email", Message: "This value is not a valid email address.", Constraint: "email"},
+			{PropertyPath: "role", Message: "This value must be one of: admin, user.", Constraint: "oneof"},
+			{PropertyPath: "age", Message: "This value must be less than or equal to 150.", Constraint: "lte"},
+			{PropertyPath: "address.street", Message: "This value must contain at most 5 elements or characters.", Constraint: "max"},
+			{PropertyPath: "name", Message: "This value is required.", Constraint: "required"},
+		}, validationErr.Violations)
+
+		problem := validationErr.Problem()
+		assert.Len(t, problem.Extensions["violations"], 5)
+	})
+
+	t.Run("Group constraints only apply to their operation", func(t *testing.T) {
+		err := processValidated(t, "PUT", "update", `{"email":"jane@example.com"}`)
+
+		// Validation passed, so the request reached the failing database
+		var internal *InternalError
+		assert.ErrorAs(t, err, &internal)
+	})
+
+	t.Run("Default constraints apply on update", func(t *testing.T) {
+		err := processValidated(t, "PUT", "update", `{"email":"jane@example.com","name":"J"}`)
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Len(t, validationErr.Violations, 1)
+		assert.Equal(t, "name", validationErr.Violations[0].PropertyPath)
+		assert.Equal(t, "min", validationErr.Violations[0].Constraint)
+	})
+
+	t.Run("Valid input reaches the database", func(t *testing.T) {
+		err := processValidated(t, "POST", "create", `{"name":"Jane","email":"jane@example.com","role":"admin"}`)
+
+		var internal *InternalError
+		assert.ErrorAs(t, err, &internal)
+	})
+
+	t.Run("Without groups in context only default constraints apply", func(t *testing.T) {
+		processor := &DefaultProcessor{DB: &testutils.MockDB{}}
+		app := fiber.New()
+		app.Post("/users", func(c *fiber.Ctx) error {
+			c.Locals("model", &ValidatedModel{})
+			_, err := processor.Process(c, nil)
+			assert.NoError(t, err)
+			return err
+		})
+
+		req := httptest.NewRequest("POST", "/users", bytes.NewBufferString(`{"email":"jane@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+}
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// VersionConfig enables optimistic concurrency control. The version field
+// changes on every write: integer fields are incremented and time fields,
+// such as UpdatedAt, set to the write time. get_item responses carry it as
+// ETag header, and writes only apply to the version they loaded, failing
+// with 412 Precondition Failed when the record changed in between.
+type VersionConfig struct {
+	Field    string // Integer or time field holding the version, versioning is disabled when empty
+	Required bool   // Reject updates, patches and deletes without If-Match header with 428
+}
+
+// versionLock is the optimistic lock of a write on a loaded record, nil
+// when versioning is disabled.
+type versionLock struct {
+	field   *schema.Field
+	version interface{} // Version of the loaded record
+}
+
+// versionField returns the version field of the model, or nil when
+// versioning is disabled.
+func versionField(c *fiber.Ctx, db GormDB, modelType interface{}) (*schema.Field, error) {
+	config, _ := c.Locals("versioning").(VersionConfig)
+	if config.Field == "" {
+		return nil, nil
+	}
+
+	modelSchema, err := parseSchema(db, modelType)
+	if err != nil {
+		return nil, err
+	}
+	field := modelSchema.LookUpField(config.Field)
+	if field == nil || field.DBName == "" {
+		return nil, NewInternalError("invalid version field "+config.Field, nil)
+	}
+	switch {
+	case field.DataType == schema.Time:
+		return field, nil
+	case (field.DataType == schema.Int || field.DataType == schema.Uint) && field.FieldType.Kind() != reflect.Ptr:
+		return field, nil
+	}
+	return nil, NewInternalError("version field "+config.Field+" must be an integer or a time", nil)
+}
+
+// etag formats a version as a strong entity tag.
+func etag(version interface{}) string {
+	switch v := version.(type) {
+	case time.Time:
+		version = v.UnixNano()
+	case *time.Time:
+		version = 0
+		if v != nil {
+			version = v.UnixNano()
+		}
+	}
+	return strconv.Quote(fmt.Sprint(version))
+}
+
+// setETag sets the ETag header to the version of the record, if versioned.
+func setETag(c *fiber.Ctx, db GormDB, instance interface{}) error {
+	field, err := versionField(c, db, instance)
+	if err != nil || field == nil {
+		return err
+	}
+	version, _ := field.ValueOf(c.UserContext(), reflect.ValueOf(instance))
+	c.Set(fiber.HeaderETag, etag(version))
+	return nil
+}
+
+// initVersion sets the first version of a created record, overriding any
+// version sent by the client. Time versions are left to GORM.
+func (p *DefaultProcessor) initVersion(c *fiber.Ctx, instance interface{}) error {
+	field, err := versionField(c, p.DB, instance)
+	if err != nil || field == nil || field.DataType == schema.Time {
+		return err
+	}
+	if err := field.Set(c.UserContext(), reflect.ValueOf(instance), 1); err != nil {
+		return NewInternalError("failed to set version", err)
+	}
+	return nil
+}
+
+// lock checks the If-Match precondition of the request against the loaded
+// record and returns the lock of its version.
+func (p *DefaultProcessor) lock(c *fiber.Ctx, existing interface{}) (*versionLock, error) {
+	field, err := versionField(c, p.DB, existing)
+	if err != nil || field == nil {
+		return nil, err
+	}
+	version, _ := field.ValueOf(c.UserContext(), reflect.ValueOf(existing))
+
+	config, _ := c.Locals("versioning").(VersionConfig)
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		if config.Required {
+			return nil, NewPreconditionRequiredError("If-Match header required")
+		}
+		return &versionLock{field: field, version: version}, nil
+	}
+
+	current := etag(version)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == current {
+			return &versionLock{field: field, version: version}, nil
+		}
+	}
+	return nil, NewPreconditionFailedError("the record has been modified")
+}
+
+// scope restricts a write to the locked version.
+func (l *versionLock) scope(db *gorm.DB) *gorm.DB {
+	if l == nil {
+		return db
+	}
+	return db.Where(clause.Eq{Column: fieldColumn(l.field), Value: l.version})
+}
+
+// next sets the version following the locked one on the instance.
+func (l *versionLock) next(c *fiber.Ctx, instance interface{}) error {
+	if l == nil {
+		return nil
+	}
+
+	var next interface{}
+	switch l.field.DataType {
+	case schema.Time:
+		next = time.Now()
+	case schema.Int:
+		next = reflect.ValueOf(l.version).Int() + 1
+	default:
+		next = reflect.ValueOf(l.version).Uint() + 1
+	}
+	if err := l.field.Set(c.UserContext(), reflect.ValueOf(instance), next); err != nil {
+		return NewInternalError("failed to set version", err)
+	}
+	return nil
+}
+
+// check reports writes that matched no row: the record changed since the
+// version was loaded.
+func (l *versionLock) check(result *gorm.DB) error {
+	if l != nil && result.Error == nil && result.RowsAffected == 0 {
+		return NewPreconditionFailedError("the record has been modified concurrently")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	stamp := time.Unix(1700000000, 123456789)
+
+	assert.Equal(t, `"3"`, etag(3))
+	assert.Equal(t, `"7"`, etag(uint64(7)))
+	assert.Equal(t, `"1700000000123456789"`, etag(stamp))
+	assert.Equal(t, `"1700000000123456789"`, etag(&stamp))
+	assert.Equal(t, `"0"`, etag((*time.Time)(nil)))
+}
+
+func TestVersionField(t *testing.T) {
+	db := testutils.NewTestDB(t, &TestModel{})
+	require.NoError(t, db.Create(&TestModel{Name: "Test 1"}).Error)
+	provider := &DefaultProvider{DB: db}
+
+	tests := map[string]struct {
+		field  string
+		status int
+		etag   string
+	}{
+		"Integer field sets the ETag": {"ID", fiber.StatusOK, `"1"`},
+		"Unknown field fails":         {"Version", fiber.StatusInternalServerError, ""},
+		"String field fails":          {"Name", fiber.StatusInternalServerError, ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/:id", func(c *fiber.Ctx) error {
+				c.Locals("model", &TestModel{})
+				c.Locals("operation", "get_item")
+				c.Locals("versioning", VersionConfig{Field: tt.field})
+				_, err := provider.Provide(c)
+				return err
+			})
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/1", nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.etag, resp.Header.Get("ETag"))
+		})
+	}
+}
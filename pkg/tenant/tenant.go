@@ -0,0 +1,141 @@
+// Package tenant scopes resources to the tenant of each request: the
+// default provider only reads rows of the tenant and the default processor
+// writes the tenant into created and updated records.
+package tenant
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/pkg/state"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultColumn holds the tenant of a row unless Config.Column is set.
+const DefaultColumn = "tenant_id"
+
+// Resolver returns the tenant of a request, or "" when it cannot tell.
+type Resolver func(c *fiber.Ctx) string
+
+// FromHeader resolves the tenant from a request header. Clients choose the
+// header freely, so it suits trusted callers or gateways that set it.
+func FromHeader(name string) Resolver {
+	return func(c *fiber.Ctx) string {
+		return c.Get(name)
+	}
+}
+
+// FromClaim resolves the tenant from a string or number claim of the JWT
+// that authenticated the request, see auth.JWTAuthenticator.
+func FromClaim(claim string) Resolver {
+	return func(c *fiber.Ctx) string {
+		principal := auth.PrincipalOf(c)
+		if principal == nil {
+			return ""
+		}
+		switch value := principal.Claims[claim].(type) {
+		case string:
+			return value
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		return ""
+	}
+}
+
+// FromSubdomain resolves the tenant from the label preceding domain in the
+// request host, e.g. "acme" for acme.example.com with domain "example.com".
+// Hosts outside domain or with several labels before it resolve nothing.
+func FromSubdomain(domain string) Resolver {
+	suffix := "." + strings.ToLower(domain)
+	return func(c *fiber.Ctx) string {
+		host := c.Hostname()
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		label, found := strings.CutSuffix(strings.ToLower(host), suffix)
+		if !found || label == "" || strings.Contains(label, ".") {
+			return ""
+		}
+		return label
+	}
+}
+
+// Config configures the tenant extension.
+type Config struct {
+	Resolvers []Resolver // Tried in order until one resolves the tenant
+	Column    string     // Column holding the tenant, defaults to DefaultColumn
+}
+
+// Extension is a query extension restricting the models with the tenant
+// column to the rows of the request's tenant, and setting the column of the
+// records they create or update. Models without the column are not scoped.
+// Requests on scoped models without a tenant are rejected with 400.
+type Extension struct {
+	resolvers []Resolver
+	column    string
+}
+
+// NewExtension creates a tenant extension, to register with
+// ResourceManager.AddExtension or ResourceConfig.Extensions.
+func NewExtension(config Config) *Extension {
+	e := &Extension{resolvers: config.Resolvers, column: config.Column}
+	if e.column == "" {
+		e.column = DefaultColumn
+	}
+	return e
+}
+
+// ID returns the tenant of the request, or "" when no resolver can tell.
+func (e *Extension) ID(c *fiber.Ctx) string {
+	for _, resolve := range e.resolvers {
+		if tenant := resolve(c); tenant != "" {
+			return tenant
+		}
+	}
+	return ""
+}
+
+// Scope implements state.QueryExtension.
+func (e *Extension) Scope(c *fiber.Ctx, model *schema.Schema) (func(*gorm.DB) *gorm.DB, error) {
+	if _, ok := model.FieldsByDBName[e.column]; !ok {
+		return nil, nil
+	}
+
+	tenant := e.ID(c)
+	if tenant == "" {
+		return nil, state.NewBadRequestError("tenant required")
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: e.column},
+			Value:  tenant,
+		})
+	}, nil
+}
+
+// Enforce implements state.WriteExtension, overwriting any tenant sent by
+// the client.
+func (e *Extension) Enforce(c *fiber.Ctx, model *schema.Schema, instance interface{}) error {
+	field, ok := model.FieldsByDBName[e.column]
+	if !ok {
+		return nil
+	}
+
+	tenant := e.ID(c)
+	if tenant == "" {
+		return state.NewBadRequestError("tenant required")
+	}
+	if err := field.Set(c.UserContext(), reflect.ValueOf(instance), tenant); err != nil {
+		return state.NewBadRequestError("invalid tenant")
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package tenant
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/pkg/auth"
+	"github.com/n3crone/gapi-platform/pkg/resource"
+	"github.com/n3crone/gapi-platform/pkg/state"
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type invoice struct {
+	ID       uint   `json:"id" gorm:"primarykey"`
+	Number   string `json:"number"`
+	TenantID string `json:"tenantId"`
+}
+
+type country struct {
+	ID   uint   `json:"id" gorm:"primarykey"`
+	Name string `json:"name"`
+}
+
+// setupTenantApp serves invoices of the tenants "acme" and "globex", and
+// countries shared by all tenants, resolving the tenant with the
+// X-Tenant header.
+func setupTenantApp(t *testing.T) (*fiber.App, *gorm.DB) {
+	db := testutils.NewTestDB(t, &invoice{}, &country{})
+	require.NoError(t, db.Create(&[]invoice{
+		{Number: "A-1", TenantID: "acme"},
+		{Number: "G-1", TenantID: "globex"},
+		{Number: "A-2", TenantID: "acme"},
+	}).Error)
+	require.NoError(t, db.Create(&country{Name: "France"}).Error)
+
+	rm := resource.NewResourceManager(db, nil)
+	rm.AddExtension(NewExtension(Config{Resolvers: []Resolver{FromHeader("X-Tenant")}}))
+	invoices := rm.CreateResource(&invoice{}, func(rc *resource.ResourceConfig) { rc.Path = "/invoices" })
+	countries := rm.CreateResource(&country{}, func(rc *resource.ResourceConfig) { rc.Path = "/countries" })
+
+	app := fiber.New()
+	invoices.RegisterRoutes(app)
+	countries.RegisterRoutes(app)
+	return app, db
+}
+
+func call(t *testing.T, app *fiber.App, method, target, tenant, payload string) (int, string) {
+	req := httptest.NewRequest(method, target, strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if tenant != "" {
+		req.Header.Set("X-Tenant", tenant)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestExtension(t *testing.T) {
+	t.Run("Lists the rows of the tenant", func(t *testing.T) {
+		app, _ := setupTenantApp(t)
+
+		status, body := call(t, app, "GET", "/invoices", "acme", "")
+		require.Equal(t, fiber.StatusOK, status)
+
+		var page struct {
+			Items      []invoice `json:"items"`
+			TotalItems int64     `json:"totalItems"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &page))
+		assert.Equal(t, int64(2), page.TotalItems)
+		for _, item := range page.Items {
+			assert.Equal(t, "acme", item.TenantID)
+		}
+	})
+
+	t.Run("Hides the items of other tenants", func(t *testing.T) {
+		app, db := setupTenantApp(t)
+
+		status, _ := call(t, app, "GET", "/invoices/1", "acme", "")
+		assert.Equal(t, fiber.StatusOK, status)
+		status, _ = call(t, app, "GET", "/invoices/2", "acme", "")
+		assert.Equal(t, fiber.StatusNotFound, status)
+		status, _ = call(t, app, "DELETE", "/invoices/2", "acme", "")
+		assert.Equal(t, fiber.StatusNotFound, status)
+
+		var count int64
+		require.NoError(t, db.Model(&invoice{}).Count(&count).Error)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("Writes the tenant of the request", func(t *testing.T) {
+		app, db := setupTenantApp(t)
+
+		status, body := call(t, app, "POST", "/invoices", "acme", `{"number":"A-3","tenantId":"globex"}`)
+		require.Equal(t, fiber.StatusOK, status, body)
+		status, body = call(t, app, "PATCH", "/invoices/1", "acme", `{"tenantId":"globex"}`)
+		require.Equal(t, fiber.StatusOK, status, body)
+
+		var tenants []string
+		require.NoError(t, db.Model(&invoice{}).Where("number IN ?", []string{"A-1", "A-3"}).Pluck("tenant_id", &tenants).Error)
+		assert.Equal(t, []string{"acme", "acme"}, tenants)
+	})
+
+	t.Run("Requires a tenant for scoped models", func(t *testing.T) {
+		app, _ := setupTenantApp(t)
+
+		status, body := call(t, app, "GET", "/invoices", "", "")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Contains(t, body, "tenant required")
+		status, _ = call(t, app, "POST", "/invoices", "", `{"number":"X-1"}`)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+
+	t.Run("Leaves models without tenant column unscoped", func(t *testing.T) {
+		app, _ := setupTenantApp(t)
+
+		status, _ := call(t, app, "GET", "/countries/1", "", "")
+		assert.Equal(t, fiber.StatusOK, status)
+		status, body := call(t, app, "POST", "/countries", "acme", `{"name":"Spain"}`)
+		assert.Equal(t, fiber.StatusOK, status, body)
+	})
+
+	t.Run("Uses the configured column", func(t *testing.T) {
+		db := testutils.NewTestDB(t, &invoice{})
+		require.NoError(t, db.Create(&[]invoice{{Number: "A-1"}, {Number: "A-2"}}).Error)
+
+		rm := resource.NewResourceManager(db, nil)
+		invoices := rm.CreateResource(&invoice{}, func(rc *resource.ResourceConfig) {
+			rc.Path = "/invoices"
+			rc.Extensions = []state.QueryExtension{NewExtension(Config{Resolvers: []Resolver{FromHeader("X-Tenant")}, Column: "number"})}
+		})
+		app := fiber.New()
+		invoices.RegisterRoutes(app)
+
+		status, _ := call(t, app, "GET", "/invoices/1", "A-1", "")
+		assert.Equal(t, fiber.StatusOK, status)
+		status, _ = call(t, app, "GET", "/invoices/2", "A-1", "")
+		assert.Equal(t, fiber.StatusNotFound, status)
+	})
+}
+
+func TestResolvers(t *testing.T) {
+	// resolve runs the resolvers in a request to target with the header and
+	// principal
+	resolve := func(t *testing.T, target string, principal *auth.Principal, resolvers ...Resolver) string {
+		var tenant string
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			if principal != nil {
+				c.Locals("principal", principal)
+			}
+			tenant = NewExtension(Config{Resolvers: resolvers}).ID(c)
+			return nil
+		})
+
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("X-Tenant", "header")
+		_, err := app.Test(req)
+		require.NoError(t, err)
+		return tenant
+	}
+
+	t.Run("Header", func(t *testing.T) {
+		assert.Equal(t, "header", resolve(t, "/", nil, FromHeader("X-Tenant")))
+		assert.Empty(t, resolve(t, "/", nil, FromHeader("X-Organization")))
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		principal := &auth.Principal{ID: "1", Claims: map[string]interface{}{"org": "acme", "org_id": float64(42), "flags": []interface{}{"x"}}}
+		assert.Equal(t, "acme", resolve(t, "/", principal, FromClaim("org")))
+		assert.Equal(t, "42", resolve(t, "/", principal, FromClaim("org_id")))
+		assert.Empty(t, resolve(t, "/", principal, FromClaim("flags")))
+		assert.Empty(t, resolve(t, "/", nil, FromClaim("org")))
+	})
+
+	t.Run("Subdomain", func(t *testing.T) {
+		tests := map[string]string{
+			"http://acme.example.com/":      "acme",
+			"http://ACME.example.com:8080/": "acme",
+			"http://example.com/":           "",
+			"http://a.b.example.com/":       "",
+			"http://acme.example.org/":      "",
+			"http://acmeexample.com/":       "",
+		}
+		for target, want := range tests {
+			assert.Equal(t, want, resolve(t, target, nil, FromSubdomain("example.com")), target)
+		}
+	})
+
+	t.Run("First resolved wins", func(t *testing.T) {
+		principal := &auth.Principal{ID: "1", Claims: map[string]interface{}{"org": "acme"}}
+		assert.Equal(t, "acme", resolve(t, "/", principal, FromClaim("org"), FromHeader("X-Tenant")))
+		assert.Equal(t, "header", resolve(t, "/", nil, FromClaim("org"), FromHeader("X-Tenant")))
+	})
+}
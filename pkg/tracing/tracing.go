@@ -0,0 +1,113 @@
+// Package tracing instruments the request pipeline with OpenTelemetry: a
+// server span per request continuing incoming W3C trace context, spans for
+// the provide, process and serialize stages, GORM query spans and trace IDs
+// in log events.
+package tracing
+
+import (
+	"context"
+	"io"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName identifies the spans created by the framework.
+const TracerName = "github.com/n3crone/gapi-platform"
+
+// Exporter sends finished spans to a tracing backend. Any OpenTelemetry span
+// exporter fits, such as the ones created by NewOTLPExporter and
+// NewStdoutExporter or the Zipkin exporter.
+type Exporter = sdktrace.SpanExporter
+
+// Config enables tracing.
+type Config struct {
+	ServiceName string   // service.name resource attribute, defaults to the Fiber AppName
+	Exporter    Exporter // Span destination, defaults to NewOTLPExporter configured from OTEL_EXPORTER_OTLP_* env vars
+	SampleRatio float64  // Share of new traces recorded, defaults to 1; incoming sampling decisions are kept
+}
+
+// NewProvider creates a tracer provider batching spans to the configured
+// exporter. The caller owns it and must shut it down to flush pending spans.
+func NewProvider(config Config) (*sdktrace.TracerProvider, error) {
+	exporter := config.Exporter
+	if exporter == nil {
+		var err error
+		if exporter, err = NewOTLPExporter(context.Background(), ""); err != nil {
+			return nil, err
+		}
+	}
+
+	ratio := config.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(config.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	), nil
+}
+
+// Install makes provider the global tracer provider and propagates W3C
+// trace context and baggage.
+func Install(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// NewOTLPExporter exports spans over OTLP/HTTP to endpoint, a URL such as
+// http://collector:4318/v1/traces. An empty endpoint is read from the
+// standard OTEL_EXPORTER_OTLP_ENDPOINT variables, then defaults to
+// localhost:4318.
+func NewOTLPExporter(ctx context.Context, endpoint string, opts ...otlptracehttp.Option) (Exporter, error) {
+	if endpoint != "" {
+		opts = append([]otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint)}, opts...)
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
+
+// NewStdoutExporter writes spans as indented JSON to w, which is handy
+// during development.
+func NewStdoutExporter(w io.Writer) (Exporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+}
+
+// Tracer returns the framework tracer of the global provider. Spans are
+// no-ops until a provider is installed.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// LogHook adds the trace_id and span_id of the event context to log events,
+// correlating logs with traces:
+//
+//	logger = logger.Hook(tracing.LogHook{})
+//	logger.Info().Ctx(c.UserContext()).Msg("Order placed")
+type LogHook struct{}
+
+// Run implements zerolog.Hook.
+func (LogHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	spanContext := trace.SpanContextFromContext(e.GetCtx())
+	if !spanContext.IsValid() {
+		return
+	}
+	e.Str("trace_id", spanContext.TraceID().String()).
+		Str("span_id", spanContext.SpanID().String())
+}
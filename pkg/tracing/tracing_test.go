@@ -0,0 +1,177 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n3crone/gapi-platform/testutils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+type Book struct {
+	ID    uint   `json:"id" gorm:"primaryKey"`
+	Title string `json:"title"`
+}
+
+// recordSpans installs a provider keeping finished spans in memory.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	Install(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestMiddleware(t *testing.T) {
+	setup := func() *fiber.App {
+		app := fiber.New()
+		app.Use(Middleware())
+		app.Use(testutils.RenderErrors)
+		app.Get("/books/:id", func(c *fiber.Ctx) error {
+			if c.Params("id") == "0" {
+				return fiber.ErrInternalServerError
+			}
+			return c.SendString("ok")
+		})
+		return app
+	}
+
+	t.Run("Continues an incoming trace", func(t *testing.T) {
+		recorder := recordSpans(t)
+
+		req := httptest.NewRequest("GET", "/books/1", nil)
+		req.Header.Set("traceparent", traceparent)
+		resp, err := setup().Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "GET /books/:id", span.Name())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+		assert.True(t, span.Parent().IsRemote())
+
+		attrs := attributes(span)
+		assert.Equal(t, "/books/:id", attrs["http.route"].AsString())
+		assert.Equal(t, int64(200), attrs["http.response.status_code"].AsInt64())
+	})
+
+	t.Run("Starts a trace and records server errors", func(t *testing.T) {
+		recorder := recordSpans(t)
+
+		resp, err := setup().Test(httptest.NewRequest("GET", "/books/0", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.False(t, spans[0].Parent().IsValid())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+	})
+
+	t.Run("Keeps the method as name for unmatched routes", func(t *testing.T) {
+		recorder := recordSpans(t)
+
+		resp, err := setup().Test(httptest.NewRequest("GET", "/unknown", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "GET", spans[0].Name())
+	})
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := recordSpans(t)
+	db := testutils.NewTestDB(t, &Book{})
+	require.NoError(t, db.Use(GormPlugin()))
+
+	ctx, parent := Tracer().Start(context.Background(), "request")
+	require.NoError(t, db.WithContext(ctx).Create(&Book{Title: "Dune"}).Error)
+	assert.Error(t, db.WithContext(ctx).First(&Book{}, 99).Error)
+	assert.Error(t, db.WithContext(ctx).Table("missing").Find(&[]Book{}).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+
+	create, notFound, failed := spans[0], spans[1], spans[2]
+	for _, span := range spans[:3] {
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+
+	assert.Equal(t, "gorm.create", create.Name())
+	attrs := attributes(create)
+	assert.Equal(t, "sqlite", attrs["db.system"].AsString())
+	assert.Equal(t, "books", attrs["db.collection.name"].AsString())
+	assert.Contains(t, attrs["db.query.text"].AsString(), "INSERT INTO `books`")
+	assert.Equal(t, int64(1), attrs["db.rows_affected"].AsInt64())
+
+	assert.Equal(t, "gorm.query", notFound.Name())
+	assert.Equal(t, codes.Unset, notFound.Status().Code, "a missing record is not an error")
+	assert.Equal(t, codes.Error, failed.Status().Code)
+}
+
+func TestLogHook(t *testing.T) {
+	recordSpans(t)
+
+	var output bytes.Buffer
+	logger := zerolog.New(&output).Hook(LogHook{})
+
+	ctx, span := Tracer().Start(context.Background(), "request")
+	defer span.End()
+	logger.Info().Ctx(ctx).Msg("traced")
+	logger.Info().Msg("untraced")
+
+	lines := bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var traced, untraced map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &traced))
+	require.NoError(t, json.Unmarshal(lines[1], &untraced))
+
+	assert.Equal(t, span.SpanContext().TraceID().String(), traced["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), traced["span_id"])
+	assert.NotContains(t, untraced, "trace_id")
+}
+
+func TestStdoutExporter(t *testing.T) {
+	var output bytes.Buffer
+	exporter, err := NewStdoutExporter(&output)
+	require.NoError(t, err)
+
+	provider, err := NewProvider(Config{ServiceName: "library", Exporter: exporter})
+	require.NoError(t, err)
+
+	_, span := provider.Tracer(TracerName).Start(context.Background(), "request")
+	span.End()
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	assert.Contains(t, output.String(), `"Name": "request"`)
+	assert.Contains(t, output.String(), `"Value": "library"`)
+}
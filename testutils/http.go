@@ -0,0 +1,56 @@
+package testutils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Response is the outcome of a request sent with Request.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   string
+}
+
+// Request sends a JSON payload to app and returns the response. headers
+// are given as name and value pairs, e.g. "If-Match", `"1"`.
+func Request(t testing.TB, app *fiber.App, method, target, payload string, headers ...string) Response {
+	t.Helper()
+
+	var body io.Reader
+	if payload != "" {
+		body = strings.NewReader(payload)
+	}
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("failed to send %s %s: %v", method, target, err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read %s %s response: %v", method, target, err)
+	}
+	return Response{Status: resp.StatusCode, Header: resp.Header, Body: string(content)}
+}
+
+// RenderErrors renders the errors of the handlers it wraps with the
+// application error handler, like the middleware installed by core.New, so
+// that the middleware registered before it sees the status sent.
+func RenderErrors(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		return c.App().ErrorHandler(c, err)
+	}
+	return nil
+}
@@ -1,9 +1,14 @@
 package testutils
 
 import (
+	"fmt"
 	"reflect"
+	"sync"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils/tests"
 )
 
 type MockDB struct {
@@ -13,6 +18,9 @@ type MockDB struct {
 	UpdateError   error
 	DeleteError   error
 	Records       []interface{}
+
+	once sync.Once
+	db   *gorm.DB
 }
 
 func (m *MockDB) Create(value interface{}) *gorm.DB {
@@ -43,12 +51,32 @@ func (m *MockDB) First(dest interface{}, conds ...interface{}) *gorm.DB {
 		return &gorm.DB{Error: m.FindByIDError}
 	}
 
-	if len(m.Records) > 0 {
-		val := reflect.ValueOf(dest).Elem()
-		record := reflect.ValueOf(m.Records[0])
-		copyFields(val, record.Elem())
+	if len(m.Records) == 0 {
+		return &gorm.DB{Error: nil}
 	}
 
+	record := reflect.ValueOf(m.Records[0]).Elem()
+	if len(conds) > 0 {
+		// The ID is given as such or as primary key condition
+		want := conds[0]
+		if eq, ok := want.(clause.Eq); ok {
+			want = eq.Value
+		}
+		found := false
+		for _, r := range m.Records {
+			candidate := reflect.ValueOf(r).Elem()
+			if id := candidate.FieldByName("ID"); id.IsValid() && fmt.Sprint(id.Interface()) == fmt.Sprint(want) {
+				record, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			return &gorm.DB{Error: gorm.ErrRecordNotFound}
+		}
+	}
+
+	copyFields(reflect.ValueOf(dest).Elem(), record)
+
 	return &gorm.DB{Error: nil}
 }
 
@@ -75,3 +103,82 @@ func copyFields(dest, src reflect.Value) {
 		}
 	}
 }
+
+// Model and Scopes start GORM chains on a session without database: its
+// callbacks answer queries from Records, ignoring their conditions, and
+// report writes as applied to one row unless the matching error is set.
+func (m *MockDB) Model(value interface{}) *gorm.DB {
+	return m.session().Model(value)
+}
+
+func (m *MockDB) Scopes(funcs ...func(*gorm.DB) *gorm.DB) *gorm.DB {
+	return m.session().Scopes(funcs...)
+}
+
+func (m *MockDB) session() *gorm.DB {
+	m.once.Do(func() {
+		db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{
+			Logger:                 logger.Default.LogMode(logger.Silent),
+			SkipDefaultTransaction: true,
+		})
+		if err != nil {
+			panic(fmt.Sprintf("testutils: failed to open mock session: %v", err))
+		}
+		_ = db.Callback().Query().Replace("gorm:query", m.query)
+		_ = db.Callback().Create().Replace("gorm:create", m.write(func() error { return m.CreateError }))
+		_ = db.Callback().Update().Replace("gorm:update", m.write(func() error { return m.UpdateError }))
+		_ = db.Callback().Delete().Replace("gorm:delete", m.write(func() error { return m.DeleteError }))
+		m.db = db
+	})
+	return m.db
+}
+
+// query loads Records into the destination of the statement: all of them
+// into slices, the first one into structs and their number into counts.
+func (m *MockDB) query(db *gorm.DB) {
+	if count, ok := db.Statement.Dest.(*int64); ok {
+		if m.FindAllError != nil {
+			db.AddError(m.FindAllError)
+			return
+		}
+		*count = int64(len(m.Records))
+		db.RowsAffected = 1
+		return
+	}
+
+	dest := reflect.Indirect(reflect.ValueOf(db.Statement.Dest))
+	if dest.Kind() == reflect.Slice {
+		if m.FindAllError != nil {
+			db.AddError(m.FindAllError)
+			return
+		}
+		for _, record := range m.Records {
+			elem := reflect.New(dest.Type().Elem()).Elem()
+			copyFields(elem, reflect.ValueOf(record).Elem())
+			dest.Set(reflect.Append(dest, elem))
+		}
+		db.RowsAffected = int64(len(m.Records))
+		return
+	}
+
+	switch {
+	case m.FindByIDError != nil:
+		db.AddError(m.FindByIDError)
+	case len(m.Records) == 0:
+		db.AddError(gorm.ErrRecordNotFound)
+	default:
+		copyFields(dest, reflect.ValueOf(m.Records[0]).Elem())
+		db.RowsAffected = 1
+	}
+}
+
+// write reports the error of the operation or one affected row.
+func (m *MockDB) write(err func() error) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if err() != nil {
+			db.AddError(err())
+			return
+		}
+		db.RowsAffected = 1
+	}
+}
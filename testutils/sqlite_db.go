@@ -0,0 +1,43 @@
+package testutils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewTestDB opens an isolated SQLite database and migrates the given models.
+// It is used by tests that need real query building (pagination, filtering,
+// sorting) which MockDB cannot emulate. The database lives in a file of the
+// test's temporary directory, in WAL mode, so that concurrent requests use
+// concurrent connections; transactions take the write lock when they begin
+// and wait for it instead of failing.
+func NewTestDB(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get test database instance: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if len(models) > 0 {
+		if err := db.AutoMigrate(models...); err != nil {
+			t.Fatalf("failed to migrate test database: %v", err)
+		}
+	}
+
+	return db
+}